@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"BoardAI/internal/api"
+	"BoardAI/internal/config"
+	"BoardAI/internal/models"
+	"BoardAI/internal/orchestrator"
+	"BoardAI/internal/queue"
+	"BoardAI/internal/repository"
+)
+
+func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	if cfg.DBURL == "" {
+		log.Fatalf("failed to load config: DB_URL is required")
+	}
+	// Без токена API отдает идеи и отчеты любому, кто до него дотянется.
+	if cfg.APIToken == "" && !api.IsLoopback(cfg.APIAddr) {
+		log.Fatalf("failed to load config: API_TOKEN is required unless API_ADDR listens on localhost (got %q)", cfg.APIAddr)
+	}
+
+	db, err := repository.NewPostgresDB(cfg.DBURL)
+	if err != nil {
+		log.Fatalf("failed to init postgres: %v", err)
+	}
+	defer func() {
+		if err := db.Close(); err != nil {
+			log.Printf("error closing db: %v", err)
+		}
+	}()
+
+	repo := repository.NewAnalysisRepository(db)
+	jobs := repository.NewJobRepository(db)
+
+	orc, err := orchestrator.NewOrchestrator(orchestrator.NewProviders(cfg), cfg)
+	if err != nil {
+		log.Fatalf("failed to init orchestrator: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	notifier := api.NewNotifier(ctx, cfg.APIWebhookSecret)
+	worker := queue.NewWorker(jobs, repo, orc, notifier, queue.NewRegistry(), models.JobSourceAPI, cfg.JobWorkers)
+	workerDone := make(chan struct{})
+	go func() {
+		defer close(workerDone)
+		worker.Run(ctx)
+	}()
+
+	srv := &http.Server{
+		Addr:              cfg.APIAddr,
+		Handler:           api.NewServer(repo, jobs, cfg.JobMaxAttempts, cfg.APIToken).Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		log.Printf("shutting down API server...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("API server shutdown error: %v", err)
+		}
+	}()
+
+	if cfg.APIToken == "" {
+		log.Printf("API_TOKEN is not set, the API accepts unauthenticated requests from localhost")
+	}
+	log.Printf("API listening on %s", cfg.APIAddr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Printf("API server error: %v", err)
+		stop()
+	}
+
+	// Прерванные задачи возвращаются в очередь, начатые попытки доставки webhook-ов завершаются, и только потом закрывается БД.
+	<-workerDone
+	notifier.Wait()
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// idea is one input item; ID comes from the CSV id column or is the row number.
+type idea struct {
+	ID   string
+	Text string
+}
+
+// readIdea reads a single idea from path, or from stdin when path is "-".
+func readIdea(path string) (idea, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return idea{}, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return idea{}, fmt.Errorf("read idea: %w", err)
+	}
+	text := strings.TrimSpace(string(data))
+	if text == "" {
+		return idea{}, fmt.Errorf("idea is empty")
+	}
+	return idea{ID: "1", Text: text}, nil
+}
+
+// readCSV reads ideas from a CSV file ("-" — stdin). If the header has an "idea" column, it is
+// used (and an optional "id" column names the rows); otherwise every row's first column is an idea.
+func readCSV(path string) ([]idea, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("read csv: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("csv is empty")
+	}
+
+	ideaCol, idCol := 0, -1
+	for i, name := range rows[0] {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "idea":
+			ideaCol = i
+			rows[0] = nil
+		case "id":
+			idCol = i
+		}
+	}
+	if rows[0] != nil {
+		// Заголовка нет — первая строка тоже идея.
+		idCol = -1
+	} else {
+		rows = rows[1:]
+	}
+
+	var ideas []idea
+	for n, row := range rows {
+		if ideaCol >= len(row) {
+			continue
+		}
+		text := strings.TrimSpace(row[ideaCol])
+		if text == "" {
+			continue
+		}
+		id := strconv.Itoa(n + 1)
+		if idCol >= 0 && idCol < len(row) && strings.TrimSpace(row[idCol]) != "" {
+			id = strings.TrimSpace(row[idCol])
+		}
+		ideas = append(ideas, idea{ID: id, Text: text})
+	}
+	if len(ideas) == 0 {
+		return nil, fmt.Errorf("csv has no ideas")
+	}
+	return ideas, nil
+}
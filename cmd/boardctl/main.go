@@ -0,0 +1,279 @@
+// Command boardctl runs a board analysis from the command line without Telegram or Postgres.
+//
+//	echo "Сеть кофеен у метро" | boardctl
+//	boardctl -file idea.txt -roles strategist,financier -format json
+//	boardctl -csv ideas.csv -model moderator=llama3.1:8b > results.jsonl
+//	boardctl -file idea.txt -attach deck.pdf -attach model.xlsx
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"BoardAI/internal/agents"
+	"BoardAI/internal/config"
+	"BoardAI/internal/document"
+	"BoardAI/internal/export"
+	"BoardAI/internal/models"
+	"BoardAI/internal/orchestrator"
+)
+
+// modelFlags collects repeated -model role=model flags.
+type modelFlags map[string]string
+
+func (m modelFlags) String() string {
+	var parts []string
+	for role, model := range m {
+		parts = append(parts, role+"="+model)
+	}
+	return strings.Join(parts, ",")
+}
+
+func (m modelFlags) Set(v string) error {
+	role, model, ok := strings.Cut(v, "=")
+	if !ok || role == "" || model == "" {
+		return fmt.Errorf("expected role=model, got %q", v)
+	}
+	m[role] = model
+	return nil
+}
+
+// attachFlags collects repeated -attach file flags.
+type attachFlags []string
+
+func (a *attachFlags) String() string {
+	return strings.Join(*a, ",")
+}
+
+func (a *attachFlags) Set(v string) error {
+	*a = append(*a, v)
+	return nil
+}
+
+// result is one line of JSON output.
+type result struct {
+	ID       string           `json:"id"`
+	Idea     string           `json:"idea"`
+	Analysis *models.Analysis `json:"analysis,omitempty"`
+	Error    string           `json:"error,omitempty"`
+	Duration string           `json:"duration"`
+}
+
+func main() {
+	modelOverrides := modelFlags{}
+	var attachFiles attachFlags
+	var (
+		ideaText  = flag.String("idea", "", "idea text; by default the idea is read from stdin")
+		ideaFile  = flag.String("file", "", "read the idea from a file (\"-\" for stdin)")
+		csvFile   = flag.String("csv", "", "run every idea from a CSV file (\"idea\" and optional \"id\" columns)")
+		roles     = flag.String("roles", "", "comma-separated expert roles to run (default: the whole board)")
+		boardFile = flag.String("board", "", "board YAML file (default: BOARD_FILE or the built-in board)")
+		format    = flag.String("format", "text", "output format: text or json")
+		debate    = flag.Int("debate", -1, "debate rounds (default: DEBATE_ROUNDS)")
+		parallel  = flag.Int("parallel", 0, "experts running at once (default: MAX_PARALLEL_AGENTS)")
+		timeout   = flag.Duration("timeout", 20*time.Minute, "timeout per idea")
+		verbose   = flag.Bool("v", false, "stream agent output to stderr")
+		listRoles = flag.Bool("list-roles", false, "print the board roles and exit")
+	)
+	flag.Var(modelOverrides, "model", "override a role model, e.g. -model financier=gemma2:9b (repeatable)")
+	flag.Var(&attachFiles, "attach", "attach a PDF, DOCX, XLSX, CSV or text document to every idea (repeatable)")
+	flag.Parse()
+
+	log.SetFlags(0)
+	log.SetPrefix("boardctl: ")
+
+	if *format != "text" && *format != "json" {
+		log.Fatalf("unknown format %q, expected text or json", *format)
+	}
+
+	if *boardFile != "" {
+		os.Setenv("BOARD_FILE", *boardFile)
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("load config: %v", err)
+	}
+	if err := configure(cfg, *roles, modelOverrides, *debate, *parallel); err != nil {
+		log.Fatal(err)
+	}
+
+	if *listRoles {
+		for _, r := range cfg.Board.Roles {
+			fmt.Printf("%-12s %-20s %s/%s\n", r.ID, r.Title(), r.Provider, r.Model)
+		}
+		return
+	}
+
+	ideas, err := readInput(*ideaText, *ideaFile, *csvFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	attachments, err := readAttachments(attachFiles)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	orc, err := orchestrator.NewOrchestrator(orchestrator.NewProviders(cfg), cfg)
+	if err != nil {
+		log.Fatalf("init orchestrator: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	var progress orchestrator.ProgressFunc
+	if *verbose {
+		progress = stderrProgress()
+	}
+
+	exporter := export.NewExporter(cfg.PDFFontDir)
+	out := json.NewEncoder(os.Stdout)
+	out.SetEscapeHTML(false)
+
+	failed := 0
+	for i, it := range ideas {
+		if ctx.Err() != nil {
+			break
+		}
+		if len(ideas) > 1 {
+			log.Printf("[%d/%d] %s", i+1, len(ideas), it.ID)
+		}
+
+		started := time.Now()
+		runCtx, cancel := context.WithTimeout(ctx, *timeout)
+		analysis, err := orc.RunAnalysis(runCtx, orchestrator.Input{Idea: it.Text, Attachments: attachments}, 0, progress, nil)
+		cancel()
+
+		res := result{ID: it.ID, Idea: it.Text, Analysis: analysis, Duration: time.Since(started).Round(time.Second).String()}
+		if err != nil {
+			failed++
+			res.Error = err.Error()
+			log.Printf("%s: %v", it.ID, err)
+		}
+
+		if *format == "json" {
+			if err := out.Encode(res); err != nil {
+				log.Fatalf("write output: %v", err)
+			}
+			continue
+		}
+		if analysis == nil {
+			continue
+		}
+		text, err := exporter.Render(analysis, cfg.Board, export.FormatMarkdown)
+		if err != nil {
+			log.Fatalf("render: %v", err)
+		}
+		if len(ideas) > 1 {
+			fmt.Printf("<!-- %s -->\n", it.ID)
+		}
+		os.Stdout.Write(text)
+		fmt.Println()
+	}
+
+	if failed > 0 || ctx.Err() != nil {
+		os.Exit(1)
+	}
+}
+
+func readAttachments(files []string) ([]models.Attachment, error) {
+	var result []models.Attachment
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			return nil, fmt.Errorf("read attachment: %w", err)
+		}
+		a, err := document.Extract(filepath.Base(f), data)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, *a)
+	}
+	return result, nil
+}
+
+// configure applies the command-line overrides on top of the environment configuration.
+func configure(cfg *config.Config, roles string, modelOverrides modelFlags, debate, parallel int) error {
+	if roles != "" {
+		var ids []string
+		for _, id := range strings.Split(roles, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids = append(ids, id)
+			}
+		}
+		b, err := cfg.Board.Select(ids)
+		if err != nil {
+			return fmt.Errorf("-roles: %w", err)
+		}
+		cfg.Board = b
+	}
+
+	for id, model := range modelOverrides {
+		found := false
+		for i := range cfg.Board.Roles {
+			if cfg.Board.Roles[i].ID == id {
+				cfg.Board.Roles[i].Model = model
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("-model: unknown role %q", id)
+		}
+	}
+
+	if debate >= 0 {
+		cfg.DebateRounds = debate
+	}
+	if parallel > 0 {
+		cfg.MaxParallelAgents = parallel
+	}
+	return nil
+}
+
+func readInput(text, file, csvPath string) ([]idea, error) {
+	switch {
+	case csvPath != "":
+		return readCSV(csvPath)
+	case text != "":
+		return []idea{{ID: "1", Text: text}}, nil
+	case file != "":
+		it, err := readIdea(file)
+		return []idea{it}, err
+	default:
+		it, err := readIdea("-")
+		return []idea{it}, err
+	}
+}
+
+// stderrProgress streams agent output to stderr. The orchestrator reports accumulated text, so
+// only the new tail is written; a shorter text means the agent started over on a fallback model.
+func stderrProgress() orchestrator.ProgressFunc {
+	var mu sync.Mutex
+	printed := map[agents.Role]int{}
+	var last agents.Role
+	return func(role agents.Role, text string) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		n := printed[role]
+		if role != last || len(text) < n {
+			fmt.Fprintf(os.Stderr, "\n--- %s ---\n", role)
+			last = role
+			n = 0
+		}
+		io.WriteString(os.Stderr, text[n:])
+		printed[role] = len(text)
+	}
+}
@@ -13,9 +13,14 @@ import (
 
 	"BoardAI/internal/bot"
 	"BoardAI/internal/config"
-	"BoardAI/internal/llm"
+	"BoardAI/internal/export"
+	"BoardAI/internal/models"
 	"BoardAI/internal/orchestrator"
+	"BoardAI/internal/queue"
+	"BoardAI/internal/quota"
 	"BoardAI/internal/repository"
+	"BoardAI/internal/speech"
+	"BoardAI/internal/state"
 )
 
 func main() {
@@ -26,6 +31,12 @@ func main() {
 	if err != nil {
 		log.Fatalf("failed to load config: %v", err)
 	}
+	if cfg.TelegramBotToken == "" {
+		log.Fatalf("failed to load config: TELEGRAM_BOT_TOKEN is required")
+	}
+	if cfg.DBURL == "" {
+		log.Fatalf("failed to load config: DB_URL is required")
+	}
 
 	db, err := repository.NewPostgresDB(cfg.DBURL)
 	if err != nil {
@@ -38,10 +49,14 @@ func main() {
 	}()
 
 	repo := repository.NewAnalysisRepository(db)
+	jobs := repository.NewJobRepository(db)
+	usage := repository.NewUsageRepository(db)
+	followups := repository.NewFollowUpRepository(db)
 
-	llmClient := llm.NewClient(cfg.OllamaBaseURL, cfg.OllamaAPIToken)
-
-	orc := orchestrator.NewOrchestrator(llmClient, cfg)
+	orc, err := orchestrator.NewOrchestrator(orchestrator.NewProviders(cfg), cfg)
+	if err != nil {
+		log.Fatalf("failed to init orchestrator: %v", err)
+	}
 
 	tgBot, err := tgbotapi.NewBotAPI(cfg.TelegramBotToken)
 	if err != nil {
@@ -64,10 +79,60 @@ func main() {
 		time.Sleep(2 * time.Second)
 	}()
 
-	stateManager := bot.NewStateManager()
-	handler := bot.NewHandler(tgBot, repo, orc, stateManager)
+	var sessions state.Store
+	if cfg.StateStore == "memory" {
+		sessions = state.NewMemoryStore(cfg.SessionTTL)
+	} else {
+		sessions = state.NewPostgresStore(db, cfg.SessionTTL)
+	}
+	go state.RunCleanup(ctx, sessions, time.Hour, log.Printf)
+
+	// Реестр выполняющихся анализов общий для обработчика и воркера: кнопка «Отменить» останавливает анализ сразу.
+	running := queue.NewRegistry()
+	limiter := quota.NewLimiter(jobs, models.JobSourceTelegram, quota.Limits{
+		MaxConcurrent: cfg.QuotaMaxConcurrent,
+		PerDay:        cfg.QuotaPerDay,
+		MaxQueueDepth: cfg.QuotaMaxQueue,
+	}, cfg.QuotaExempt)
+	transcriber, err := speech.New(speech.Config{
+		Backend:  cfg.Transcriber,
+		URL:      cfg.TranscriberURL,
+		Model:    cfg.TranscriberModel,
+		APIToken: cfg.TranscriberAPIToken,
+		Language: cfg.TranscriberLanguage,
+	})
+	if err != nil {
+		log.Fatalf("failed to init transcriber: %v", err)
+	}
+	handler := bot.NewHandler(tgBot, repo, jobs, usage, followups, cfg.JobMaxAttempts, orc,
+		export.NewExporter(cfg.PDFFontDir), sessions, running, limiter, cfg.InterviewMaxIdeaLength, transcriber, cfg.IsAdmin)
 
-	if err := handler.Run(ctx); err != nil {
+	worker := queue.NewWorker(jobs, repo, orc, handler, running, models.JobSourceTelegram, cfg.JobWorkers)
+	workerDone := make(chan struct{})
+	go func() {
+		defer close(workerDone)
+		worker.Run(ctx)
+	}()
+	// Ждем, пока воркер вернет прерванные задачи в очередь, и только потом закрываем БД.
+	defer func() {
+		cancel()
+		<-workerDone
+	}()
+
+	if cfg.TelegramWebhookURL != "" {
+		err = handler.RunWebhook(ctx, bot.WebhookConfig{
+			URL:         cfg.TelegramWebhookURL,
+			Listen:      cfg.TelegramWebhookListen,
+			PathSecret:  cfg.TelegramWebhookPathSecret,
+			SecretToken: cfg.TelegramWebhookSecretToken,
+			CertFile:    cfg.TelegramWebhookCert,
+			KeyFile:     cfg.TelegramWebhookKey,
+			SelfSigned:  cfg.TelegramWebhookSelfSigned,
+		})
+	} else {
+		err = handler.Run(ctx)
+	}
+	if err != nil {
 		log.Fatalf("bot stopped with error: %v", err)
 	}
 }
@@ -3,6 +3,10 @@ module BoardAI
 go 1.25.4
 
 require (
+	github.com/go-pdf/fpdf v0.9.0
 	github.com/go-telegram-bot-api/telegram-bot-api/v5 v5.5.1
+	github.com/ledongthuc/pdf v0.0.0-20250511090121-5959a4027728
 	github.com/lib/pq v1.11.2
 )
+
+require gopkg.in/yaml.v3 v3.0.1
@@ -1,68 +1,72 @@
 package agents
 
 import (
-	"BoardAI/internal/config"
+	"BoardAI/internal/board"
 	"BoardAI/internal/llm"
 	"context"
+	"fmt"
+	"log"
+	"strings"
 )
 
+// Role is the id of a board seat as defined in the board file (e.g. "strategist").
 type Role string
 
-const (
-	RoleStrategist Role = "strategist_optimist"
-	RoleFinancier  Role = "financier"
-	RoleAuditor    Role = "auditor_skeptic"
-	RoleAnalyst    Role = "market_analyst"
-	RoleModerator  Role = "moderator"
-)
+// TextFunc receives the answer accumulated so far while it streams in. If the agent switches
+// to a fallback model, the text starts over from the fallback's first token.
+type TextFunc func(text string)
 
 type Agent interface {
 	Role() Role
 	Model() string
 	SystemPrompt() string
 	Run(ctx context.Context, idea string) (string, error)
+	RunStream(ctx context.Context, idea string, onText TextFunc) (string, error)
+	// RunJSON asks for a JSON answer, decodes it into out and re-asks on schema violations.
+	RunJSON(ctx context.Context, input string, out llm.Validator) (string, error)
+	// Chat continues a conversation: the agent's system prompt is put before history, which must
+	// end with a user message.
+	Chat(ctx context.Context, history []llm.ChatMessage) (string, error)
 }
 
 type baseAgent struct {
 	role         Role
-	model        string
+	models       []string
 	systemPrompt string
-	client       *llm.Client
+	opts         llm.Options
+	provider     llm.Provider
 }
 
-func NewAgentsFromConfig(cli *llm.Client, cfg *config.Config) map[Role]Agent {
-	return map[Role]Agent{
-		RoleStrategist: &baseAgent{
-			role:         RoleStrategist,
-			model:        cfg.ModelStrategist,
-			systemPrompt: llm.SystemPromptStrategist,
-			client:       cli,
-		},
-		RoleFinancier: &baseAgent{
-			role:         RoleFinancier,
-			model:        cfg.ModelFinancier,
-			systemPrompt: llm.SystemPromptFinancier,
-			client:       cli,
-		},
-		RoleAuditor: &baseAgent{
-			role:         RoleAuditor,
-			model:        cfg.ModelAuditor,
-			systemPrompt: llm.SystemPromptAuditor,
-			client:       cli,
-		},
-		RoleAnalyst: &baseAgent{
-			role:         RoleAnalyst,
-			model:        cfg.ModelAnalyst,
-			systemPrompt: llm.SystemPromptAnalyst,
-			client:       cli,
-		},
-		RoleModerator: &baseAgent{
-			role:         RoleModerator,
-			model:        cfg.ModelModerator,
-			systemPrompt: llm.SystemPromptModerator,
-			client:       cli,
-		},
+// NewAgentsFromBoard creates an agent for every role of the board definition, each bound to
+// the provider its role selects.
+func NewAgentsFromBoard(providers llm.Registry, b *board.Board) (map[Role]Agent, error) {
+	result := make(map[Role]Agent, len(b.Roles))
+	for _, r := range b.Roles {
+		ag, err := NewAgent(providers, r)
+		if err != nil {
+			return nil, err
+		}
+		result[Role(r.ID)] = ag
 	}
+	return result, nil
+}
+
+// NewAgent creates an agent for a single role definition.
+func NewAgent(providers llm.Registry, r board.Role) (Agent, error) {
+	provider, err := providers.Get(r.Provider)
+	if err != nil {
+		return nil, fmt.Errorf("role %q: %w", r.ID, err)
+	}
+	return &baseAgent{
+		role:         Role(r.ID),
+		models:       r.Models(),
+		systemPrompt: r.Prompt,
+		opts: llm.Options{
+			Temperature: r.Temp(),
+			MaxTokens:   r.MaxTokens,
+		},
+		provider: provider,
+	}, nil
 }
 
 func (a *baseAgent) Role() Role {
@@ -70,7 +74,7 @@ func (a *baseAgent) Role() Role {
 }
 
 func (a *baseAgent) Model() string {
-	return a.model
+	return a.models[0]
 }
 
 func (a *baseAgent) SystemPrompt() string {
@@ -78,5 +82,59 @@ func (a *baseAgent) SystemPrompt() string {
 }
 
 func (a *baseAgent) Run(ctx context.Context, idea string) (string, error) {
-	return a.client.Chat(ctx, a.model, a.systemPrompt, idea)
+	return a.withFallback(ctx, func(model string) (llm.Response, error) {
+		return a.provider.Chat(ctx, llm.NewRequest(model, a.systemPrompt, idea, a.opts))
+	})
+}
+
+func (a *baseAgent) RunStream(ctx context.Context, idea string, onText TextFunc) (string, error) {
+	return a.withFallback(ctx, func(model string) (llm.Response, error) {
+		var sb strings.Builder
+		return a.provider.ChatStream(ctx, llm.NewRequest(model, a.systemPrompt, idea, a.opts), func(delta string) {
+			sb.WriteString(delta)
+			if onText != nil {
+				onText(sb.String())
+			}
+		})
+	})
+}
+
+func (a *baseAgent) RunJSON(ctx context.Context, input string, out llm.Validator) (string, error) {
+	return a.withFallback(ctx, func(model string) (llm.Response, error) {
+		return llm.ChatJSON(ctx, a.provider, llm.NewRequest(model, a.systemPrompt, input, a.opts), out)
+	})
+}
+
+func (a *baseAgent) Chat(ctx context.Context, history []llm.ChatMessage) (string, error) {
+	messages := append([]llm.ChatMessage{{Role: "system", Content: a.systemPrompt}}, history...)
+	return a.withFallback(ctx, func(model string) (llm.Response, error) {
+		return a.provider.Chat(ctx, llm.Request{Model: model, Messages: messages, Options: a.opts})
+	})
+}
+
+// withFallback tries the primary model and then each fallback until one succeeds.
+// The client has already retried transient errors, so any error here moves on to the next model.
+// The usage of every call, failed ones included, goes to the meter attached to ctx.
+func (a *baseAgent) withFallback(ctx context.Context, call func(model string) (llm.Response, error)) (string, error) {
+	var lastErr error
+	for i, model := range a.models {
+		resp, err := call(model)
+		llm.RecordUsage(ctx, resp.Usage)
+		if err == nil {
+			return resp.Content, nil
+		}
+		if ctx.Err() != nil {
+			return "", err
+		}
+
+		lastErr = fmt.Errorf("model %s: %w", model, err)
+		if i < len(a.models)-1 {
+			reason := "keeps failing"
+			if llm.IsModelNotFound(err) {
+				reason = "is not available"
+			}
+			log.Printf("%s: model %s %s (%v), falling back to %s", a.role, model, reason, err, a.models[i+1])
+		}
+	}
+	return "", lastErr
 }
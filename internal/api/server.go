@@ -0,0 +1,275 @@
+package api
+
+import (
+	"BoardAI/internal/models"
+	"BoardAI/internal/repository"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	// maxIdeaLength ограничивает идею примерно так же, как Telegram ограничивает сообщение.
+	maxIdeaLength = 4096
+	maxBodyBytes  = 64 << 10
+)
+
+// Server exposes analyses over a JSON HTTP API. Analyses run asynchronously through the same
+// Postgres job queue as the bot; the result is saved to the analyses table when it is ready.
+type Server struct {
+	repo        repository.AnalysisRepository
+	jobs        repository.JobRepository
+	maxAttempts int
+	token       string
+}
+
+func NewServer(repo repository.AnalysisRepository, jobs repository.JobRepository, maxAttempts int, token string) *Server {
+	return &Server{
+		repo:        repo,
+		jobs:        jobs,
+		maxAttempts: maxAttempts,
+		token:       token,
+	}
+}
+
+// Handler returns the HTTP handler with all API routes.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /analyses", s.createAnalysis)
+	mux.HandleFunc("GET /analyses", s.listAnalyses)
+	mux.HandleFunc("GET /analyses/{id}", s.getAnalysis)
+	mux.HandleFunc("GET /jobs/{id}", s.getJob)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	return s.authorize(mux)
+}
+
+// authorize checks the bearer token when one is configured; /healthz stays open for probes.
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.token != "" && r.URL.Path != "/healthz" {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(s.token)) != 1 {
+				writeError(w, http.StatusUnauthorized, "invalid or missing API token")
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// IsLoopback reports whether the listen address addr ("host:port") accepts connections from
+// this machine only. An empty host listens on every interface.
+func IsLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+type createAnalysisRequest struct {
+	Idea   string `json:"idea"`
+	UserID int64  `json:"user_id"`
+	// CallbackURL получает POST с результатом, когда анализ завершится.
+	CallbackURL string `json:"callback_url"`
+}
+
+func (req *createAnalysisRequest) validate() error {
+	req.Idea = strings.TrimSpace(req.Idea)
+	if req.Idea == "" {
+		return errors.New("idea is required")
+	}
+	if len([]rune(req.Idea)) > maxIdeaLength {
+		return fmt.Errorf("idea must be at most %d characters", maxIdeaLength)
+	}
+	if req.UserID == 0 {
+		return errors.New("user_id is required")
+	}
+	if req.CallbackURL != "" {
+		u, err := url.Parse(req.CallbackURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.New("callback_url must be an absolute http(s) URL")
+		}
+		// Имена проверяются уже при соединении, а явно внутренний адрес отклоняем сразу.
+		host := u.Hostname()
+		if ip, err := netip.ParseAddr(host); host == "localhost" || (err == nil && !IsPublicAddr(ip)) {
+			return errors.New("callback_url must point to a public address")
+		}
+	}
+	return nil
+}
+
+// jobResponse is the public view of a job; chat and worker details stay internal.
+type jobResponse struct {
+	ID         int64            `json:"id"`
+	Status     models.JobStatus `json:"status"`
+	UserID     int64            `json:"user_id"`
+	Attempts   int              `json:"attempts"`
+	LastError  string           `json:"last_error,omitempty"`
+	AnalysisID int64            `json:"analysis_id,omitempty"`
+	CreatedAt  time.Time        `json:"created_at"`
+	FinishedAt *time.Time       `json:"finished_at,omitempty"`
+}
+
+func newJobResponse(j *models.Job) jobResponse {
+	return jobResponse{
+		ID:         j.ID,
+		Status:     j.Status,
+		UserID:     j.UserID,
+		Attempts:   j.Attempts,
+		LastError:  j.LastError,
+		AnalysisID: j.AnalysisID,
+		CreatedAt:  j.CreatedAt,
+		FinishedAt: j.FinishedAt,
+	}
+}
+
+func (s *Server) createAnalysis(w http.ResponseWriter, r *http.Request) {
+	var req createAnalysisRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("invalid JSON body: %v", err))
+		return
+	}
+	if err := req.validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	job := &models.Job{
+		Source:      models.JobSourceAPI,
+		UserID:      req.UserID,
+		IdeaText:    req.Idea,
+		CallbackURL: req.CallbackURL,
+		MaxAttempts: s.maxAttempts,
+	}
+	if err := s.jobs.Enqueue(r.Context(), job); err != nil {
+		log.Printf("api: enqueue job error: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to queue analysis")
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/jobs/%d", job.ID))
+	writeJSON(w, http.StatusAccepted, newJobResponse(job))
+}
+
+func (s *Server) getJob(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	job, err := s.jobs.Get(r.Context(), id)
+	if err != nil {
+		log.Printf("api: get job error: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to load job")
+		return
+	}
+	// Задачи Telegram через API не видны.
+	if job == nil || job.Source != models.JobSourceAPI {
+		writeError(w, http.StatusNotFound, "job not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, newJobResponse(job))
+}
+
+func (s *Server) getAnalysis(w http.ResponseWriter, r *http.Request) {
+	id, ok := pathID(w, r)
+	if !ok {
+		return
+	}
+
+	// Анализы из Telegram через API не видны, как и их задачи.
+	a, err := s.repo.GetFromSource(r.Context(), id, models.JobSourceAPI)
+	if err != nil {
+		log.Printf("api: get analysis error: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to load analysis")
+		return
+	}
+	if a == nil {
+		writeError(w, http.StatusNotFound, "analysis not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, a)
+}
+
+func (s *Server) listAnalyses(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	userID, err := strconv.ParseInt(q.Get("user"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "user query parameter is required")
+		return
+	}
+	limit, err := queryInt(q, "limit", defaultPageSize)
+	if err != nil || limit < 1 || limit > maxPageSize {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("limit must be within 1..%d", maxPageSize))
+		return
+	}
+	offset, err := queryInt(q, "offset", 0)
+	if err != nil || offset < 0 {
+		writeError(w, http.StatusBadRequest, "offset must be a non-negative integer")
+		return
+	}
+
+	analyses, err := s.repo.ListByUserFromSource(r.Context(), userID, models.JobSourceAPI, limit, offset)
+	if err != nil {
+		log.Printf("api: list analyses error: %v", err)
+		writeError(w, http.StatusInternalServerError, "failed to list analyses")
+		return
+	}
+	if analyses == nil {
+		analyses = []*models.Analysis{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"analyses": analyses,
+		"limit":    limit,
+		"offset":   offset,
+	})
+}
+
+func pathID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		writeError(w, http.StatusBadRequest, "id must be a positive integer")
+		return 0, false
+	}
+	return id, true
+}
+
+func queryInt(q url.Values, key string, def int) (int, error) {
+	v := q.Get(key)
+	if v == "" {
+		return def, nil
+	}
+	return strconv.Atoi(v)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("api: write response error: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
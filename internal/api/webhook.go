@@ -0,0 +1,212 @@
+package api
+
+import (
+	"BoardAI/internal/models"
+	"BoardAI/internal/orchestrator"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	webhookAttempts = 5
+	webhookTimeout  = 15 * time.Second
+	webhookBackoff  = 2 * time.Second
+	// SignatureHeader carries "sha256=<hex HMAC of the body>" when a webhook secret is configured.
+	SignatureHeader = "X-BoardAI-Signature"
+)
+
+// WebhookPayload is POSTed to the job's callback_url once the job is done or has failed for good.
+type WebhookPayload struct {
+	JobID      int64            `json:"job_id"`
+	Status     models.JobStatus `json:"status"`
+	UserID     int64            `json:"user_id"`
+	AnalysisID int64            `json:"analysis_id,omitempty"`
+	Analysis   *models.Analysis `json:"analysis,omitempty"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// Notifier implements queue.Notifier for API jobs: it calls webhooks. The worker has already
+// saved the analysis by then.
+type Notifier struct {
+	// ctx отменяется при остановке: паузы между попытками доставки прерываются.
+	ctx    context.Context
+	secret string
+	client *http.Client
+	// pending позволяет дождаться доставки webhook-ов при остановке.
+	pending sync.WaitGroup
+}
+
+// NewNotifier returns a notifier that stops retrying webhooks once ctx is done.
+func NewNotifier(ctx context.Context, secret string) *Notifier {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Через прокси проверка адреса при соединении ничего бы не дала.
+	transport.Proxy = nil
+	transport.DialContext = (&net.Dialer{Timeout: webhookTimeout, Control: publicOnly}).DialContext
+	return &Notifier{
+		ctx:    ctx,
+		secret: secret,
+		client: &http.Client{Timeout: webhookTimeout, Transport: transport},
+	}
+}
+
+// JobStarted implements queue.Notifier; API clients poll or wait for the webhook instead of live progress.
+func (n *Notifier) JobStarted(job *models.Job) orchestrator.ProgressFunc {
+	return nil
+}
+
+// JobCompleted implements queue.Notifier.
+func (n *Notifier) JobCompleted(job *models.Job, analysis *models.Analysis) {
+	n.deliver(job, WebhookPayload{
+		JobID:      job.ID,
+		Status:     models.JobDone,
+		UserID:     job.UserID,
+		AnalysisID: analysis.ID,
+		Analysis:   analysis,
+	})
+}
+
+// JobFailed implements queue.Notifier; only the final failure is reported.
+func (n *Notifier) JobFailed(job *models.Job, err error, willRetry bool) {
+	if willRetry {
+		return
+	}
+	n.deliver(job, WebhookPayload{
+		JobID:      job.ID,
+		Status:     models.JobFailed,
+		UserID:     job.UserID,
+		AnalysisID: job.AnalysisID,
+		Error:      err.Error(),
+	})
+}
+
+// JobCancelled implements queue.Notifier.
+func (n *Notifier) JobCancelled(job *models.Job) {
+	n.deliver(job, WebhookPayload{
+		JobID:      job.ID,
+		Status:     models.JobCancelled,
+		UserID:     job.UserID,
+		AnalysisID: job.AnalysisID,
+	})
+}
+
+// Wait blocks until webhook deliveries finish; after ctx is done they stop before the next retry.
+func (n *Notifier) Wait() {
+	n.pending.Wait()
+}
+
+func (n *Notifier) deliver(job *models.Job, payload WebhookPayload) {
+	if job.CallbackURL == "" {
+		return
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("api: job %d: marshal webhook: %v", job.ID, err)
+		return
+	}
+
+	// Доставка не должна занимать слот воркера, поэтому идет в фоне.
+	n.pending.Add(1)
+	go func() {
+		defer n.pending.Done()
+		for attempt := 1; attempt <= webhookAttempts; attempt++ {
+			err := n.post(job.CallbackURL, body)
+			if err == nil {
+				return
+			}
+			log.Printf("api: job %d: webhook attempt %d/%d: %v", job.ID, attempt, webhookAttempts, err)
+			if attempt == webhookAttempts {
+				return
+			}
+			timer := time.NewTimer(webhookBackoff << (attempt - 1))
+			select {
+			case <-timer.C:
+			case <-n.ctx.Done():
+				timer.Stop()
+				log.Printf("api: job %d: shutting down, webhook is not delivered", job.ID)
+				return
+			}
+		}
+	}()
+}
+
+func (n *Notifier) post(url string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if n.secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(n.secret, body))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("callback responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// nonPublicPrefixes are the ranges besides loopback, private and link-local ones that never
+// belong to a webhook receiver on the internet.
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// IsPublicAddr reports whether a webhook may be delivered to ip: loopback, private, link-local
+// (cloud metadata included) and similar internal addresses are refused.
+func IsPublicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsValid() || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, p := range nonPublicPrefixes {
+		if p.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// publicOnly is a net.Dialer Control hook that refuses connections to non-public addresses.
+// It sees the address after DNS resolution, so a callback host that resolves to the internal
+// network, or a redirect there, is refused too.
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !IsPublicAddr(ip) {
+		return fmt.Errorf("callback address %s is not public", ip)
+	}
+	return nil
+}
+
+// Sign returns the hex HMAC-SHA256 of body; receivers compare it with the signature header.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
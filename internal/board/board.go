@@ -0,0 +1,252 @@
+package board
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"BoardAI/internal/llm"
+)
+
+const (
+	defaultTemperature   float32 = 0.1
+	defaultMaxTokens             = 500
+	defaultContextWindow         = 8192
+)
+
+// Role describes one seat on the board: an expert or the moderator.
+type Role struct {
+	ID    string `yaml:"id"`
+	Name  string `yaml:"name"`
+	Emoji string `yaml:"emoji"`
+	// Provider — бэкенд LLM: openai (по умолчанию), ollama или anthropic.
+	Provider string `yaml:"provider"`
+	Model    string `yaml:"model"`
+	// FallbackModels пробуются по порядку, если основной модели нет или она продолжает падать.
+	FallbackModels []string `yaml:"fallback_models"`
+	Temperature    *float32 `yaml:"temperature"`
+	MaxTokens      int      `yaml:"max_tokens"`
+	Prompt         string   `yaml:"prompt"`
+	// ContextWindow — размер контекста модели в токенах; по нему модератору считается бюджет на отчеты.
+	ContextWindow int `yaml:"context_window"`
+	Order         int `yaml:"order"`
+	// FeedsModerator решает, попадает ли отчет эксперта к модератору. По умолчанию — да.
+	FeedsModerator *bool `yaml:"feeds_moderator"`
+	Moderator      bool  `yaml:"moderator"`
+	// Structured просит эксперта вернуть JSON-оценку (summary, score, risks, recommendations).
+	// Модератор всегда отвечает структурированным вердиктом.
+	Structured bool `yaml:"structured"`
+	// Tables — эксперт получает таблицы из приложенных файлов (финансовую модель) целиком,
+	// а не только подходящие к его роли фрагменты.
+	Tables bool `yaml:"tables"`
+}
+
+// Title returns the emoji and display name, e.g. "📈 Стратег".
+func (r Role) Title() string {
+	if r.Emoji == "" {
+		return r.Name
+	}
+	return r.Emoji + " " + r.Name
+}
+
+func (r Role) Temp() float32 {
+	if r.Temperature == nil {
+		return defaultTemperature
+	}
+	return *r.Temperature
+}
+
+func (r Role) ReportsToModerator() bool {
+	return r.FeedsModerator == nil || *r.FeedsModerator
+}
+
+// Board is the full board definition: experts in presentation order plus exactly one moderator.
+type Board struct {
+	Roles []Role `yaml:"roles"`
+}
+
+// Load reads a board definition from a YAML file.
+func Load(path string) (*Board, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read board file: %w", err)
+	}
+
+	var b Board
+	if err := yaml.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("parse board file: %w", err)
+	}
+
+	if err := b.normalize(); err != nil {
+		return nil, fmt.Errorf("board file %s: %w", path, err)
+	}
+	return &b, nil
+}
+
+// Default returns the built-in board that mirrors the classic five-seat setup.
+func Default() *Board {
+	b := &Board{Roles: []Role{
+		{ID: "strategist", Name: "Стратег", Emoji: "📈", Model: "llama3:8b", Prompt: llm.SystemPromptStrategist, Order: 1},
+		{ID: "financier", Name: "Финансист", Emoji: "💰", Model: "gemma2:9b", Prompt: llm.SystemPromptFinancier, Order: 2, Tables: true},
+		{ID: "auditor", Name: "Аудитор", Emoji: "🔍", Model: "mistral:7b", Prompt: llm.SystemPromptAuditor, Order: 3},
+		{ID: "analyst", Name: "Аналитик рынка", Emoji: "📊", Model: "qwen2.5:7b", Prompt: llm.SystemPromptAnalyst, Order: 4},
+		{ID: "moderator", Name: "Модератор", Emoji: "👨‍💼", Model: "llama3.1:8b", Prompt: llm.SystemPromptModerator, Moderator: true},
+	}}
+	// Встроенная доска заведомо корректна.
+	_ = b.normalize()
+	return b
+}
+
+// OverrideModels replaces role models with values returned by lookup, which is called with
+// env-style keys like MODEL_STRATEGIST and MODEL_STRATEGIST_FALLBACKS (comma-separated).
+// Empty results leave the role untouched.
+func (b *Board) OverrideModels(lookup func(key string) string) {
+	for i := range b.Roles {
+		key := ModelEnvKey(b.Roles[i].ID)
+		if m := lookup(key); m != "" {
+			b.Roles[i].Model = m
+		}
+		if f := lookup(key + "_FALLBACKS"); f != "" {
+			var fallbacks []string
+			for _, m := range strings.Split(f, ",") {
+				if m = strings.TrimSpace(m); m != "" {
+					fallbacks = append(fallbacks, m)
+				}
+			}
+			b.Roles[i].FallbackModels = fallbacks
+		}
+	}
+}
+
+// Models returns the primary model followed by the fallbacks.
+func (r Role) Models() []string {
+	return append([]string{r.Model}, r.FallbackModels...)
+}
+
+// ModelEnvKey returns the environment variable that overrides the model of a role.
+func ModelEnvKey(roleID string) string {
+	return "MODEL_" + strings.ToUpper(strings.NewReplacer("-", "_", " ", "_").Replace(roleID))
+}
+
+// Experts returns all non-moderator roles sorted by Order.
+func (b *Board) Experts() []Role {
+	var experts []Role
+	for _, r := range b.Roles {
+		if !r.Moderator {
+			experts = append(experts, r)
+		}
+	}
+	return experts
+}
+
+// Moderator returns the moderator role.
+func (b *Board) Moderator() Role {
+	for _, r := range b.Roles {
+		if r.Moderator {
+			return r
+		}
+	}
+	return Role{}
+}
+
+// Role looks a role up by id.
+func (b *Board) Role(id string) (Role, bool) {
+	for _, r := range b.Roles {
+		if r.ID == id {
+			return r, true
+		}
+	}
+	return Role{}, false
+}
+
+// Select returns a copy of the board with only the given experts; the moderator is always kept.
+func (b *Board) Select(ids []string) (*Board, error) {
+	keep := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		r, ok := b.Role(id)
+		if !ok {
+			return nil, fmt.Errorf("unknown role %q", id)
+		}
+		if r.Moderator {
+			continue
+		}
+		keep[id] = true
+	}
+	if len(keep) == 0 {
+		return nil, fmt.Errorf("at least one expert role is required")
+	}
+
+	selected := &Board{}
+	for _, r := range b.Roles {
+		if r.Moderator || keep[r.ID] {
+			selected.Roles = append(selected.Roles, r)
+		}
+	}
+	return selected, nil
+}
+
+// Title returns the display title of a role, falling back to its id for roles that are
+// no longer on the board (e.g. in old stored analyses).
+func (b *Board) Title(id string) string {
+	if r, ok := b.Role(id); ok {
+		return r.Title()
+	}
+	return id
+}
+
+// normalize validates the definition, fills defaults and sorts roles by Order.
+func (b *Board) normalize() error {
+	if len(b.Roles) == 0 {
+		return fmt.Errorf("no roles defined")
+	}
+
+	seen := make(map[string]bool, len(b.Roles))
+	moderators := 0
+	for i := range b.Roles {
+		r := &b.Roles[i]
+		if r.ID == "" {
+			return fmt.Errorf("role #%d: id is required", i+1)
+		}
+		if seen[r.ID] {
+			return fmt.Errorf("role %q: duplicate id", r.ID)
+		}
+		seen[r.ID] = true
+
+		if r.Model == "" {
+			return fmt.Errorf("role %q: model is required", r.ID)
+		}
+		if r.Prompt == "" {
+			return fmt.Errorf("role %q: prompt is required", r.ID)
+		}
+		if r.Name == "" {
+			r.Name = r.ID
+		}
+		if r.Provider == "" {
+			r.Provider = llm.ProviderOpenAI
+		}
+		if r.MaxTokens <= 0 {
+			r.MaxTokens = defaultMaxTokens
+		}
+		if r.ContextWindow <= 0 {
+			r.ContextWindow = defaultContextWindow
+		}
+		if r.Moderator {
+			moderators++
+		}
+	}
+
+	if moderators != 1 {
+		return fmt.Errorf("exactly one moderator role is required, got %d", moderators)
+	}
+	if len(b.Roles) == 1 {
+		return fmt.Errorf("at least one expert role is required")
+	}
+
+	sort.SliceStable(b.Roles, func(i, j int) bool {
+		return b.Roles[i].Order < b.Roles[j].Order
+	})
+	return nil
+}
@@ -0,0 +1,171 @@
+package bot
+
+import (
+	"BoardAI/internal/document"
+	"BoardAI/internal/models"
+	"BoardAI/internal/state"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// maxAttachments — сколько документов можно приложить к одной идее.
+	maxAttachments = 5
+	// downloadDocumentTimeout ограничивает скачивание одного документа.
+	downloadDocumentTimeout = 2 * time.Minute
+)
+
+// handleDocument reads a file the user sent in the background and keeps it for the next analysis.
+// With a caption the caption is taken as the idea and the analysis starts at once.
+func (h *Handler) handleDocument(ctx context.Context, msg *tgbotapi.Message) {
+	chatID, userID := msg.Chat.ID, msg.From.ID
+	doc := msg.Document
+
+	if !document.Supported(doc.FileName) {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Этот формат я не читаю. Пришлите PDF, DOCX, XLSX, CSV или TXT."))
+		return
+	}
+	if doc.FileSize > document.MaxFileSize {
+		h.bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("Файл больше %d МБ — Telegram не дает боту его скачать.", document.MaxFileSize>>20)))
+		return
+	}
+	// Нет смысла читать документ, если анализ все равно не запустится.
+	if _, ok := h.checkQuota(ctx, chatID, userID); !ok {
+		return
+	}
+
+	s, err := h.sessions.Get(ctx, userID)
+	if err != nil {
+		log.Printf("get session error: %v", err)
+	}
+	if s != nil && len(s.PendingAttachments) >= maxAttachments {
+		h.bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("К одной идее можно приложить не больше %d документов. Опишите идею, чтобы запустить анализ.", maxAttachments)))
+		return
+	}
+
+	// Скачивание и разбор большого PDF занимают время, поэтому идут в фоне; одновременно читается
+	// не больше maxAttachments файлов пользователя — столько к идее все равно не приложить.
+	v, _ := h.reading.LoadOrStore(userID, new(atomic.Int32))
+	inFlight := v.(*atomic.Int32)
+	if inFlight.Add(1) > maxAttachments {
+		inFlight.Add(-1)
+		h.bot.Send(tgbotapi.NewMessage(chatID, "⏳ Еще читаю присланные файлы, подождите."))
+		return
+	}
+
+	h.bot.Request(tgbotapi.NewChatAction(chatID, tgbotapi.ChatTyping))
+	go func() {
+		defer inFlight.Add(-1)
+		h.readDocument(ctx, msg)
+	}()
+}
+
+// readDocument downloads and parses the document and adds it to the pending attachments.
+func (h *Handler) readDocument(ctx context.Context, msg *tgbotapi.Message) {
+	chatID, userID := msg.Chat.ID, msg.From.ID
+	doc := msg.Document
+
+	dctx, cancel := context.WithTimeout(ctx, downloadDocumentTimeout)
+	data, err := h.downloadFile(dctx, doc.FileID, document.MaxFileSize)
+	cancel()
+	if err != nil {
+		log.Printf("download document error: %v", err)
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Не удалось скачать файл. Попробуйте отправить его еще раз."))
+		return
+	}
+	a, err := document.Extract(doc.FileName, data)
+	if err != nil {
+		log.Printf("extract document error: %v", err)
+		h.bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("Не удалось прочитать «%s»: в файле нет текста или он поврежден.", doc.FileName)))
+		return
+	}
+
+	caption := strings.TrimSpace(msg.Caption)
+	var pending int
+	full := false
+	h.updateSession(ctx, userID, func(s *state.Session) {
+		// Пока файл читался, могли дочитаться другие.
+		if len(s.PendingAttachments) >= maxAttachments {
+			full = true
+			return
+		}
+		s.PendingAttachments = append(s.PendingAttachments, *a)
+		pending = len(s.PendingAttachments)
+		if caption == "" {
+			s.State = stateWaitingQuery
+		}
+	})
+	if full {
+		h.bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("К одной идее можно приложить не больше %d документов — «%s» не добавлен.", maxAttachments, doc.FileName)))
+		return
+	}
+
+	if caption != "" {
+		h.processIdea(ctx, chatID, userID, caption, "")
+		return
+	}
+
+	text := fmt.Sprintf("📎 %s\n\nДокументов к идее: %d. Теперь опишите идею одним сообщением или пришлите еще файлы.",
+		describeAttachment(a), pending)
+	h.bot.Send(tgbotapi.NewMessage(chatID, text))
+}
+
+// downloadFile fetches a file from Telegram servers, refusing files larger than maxSize bytes.
+func (h *Handler) downloadFile(ctx context.Context, fileID string, maxSize int64) ([]byte, error) {
+	fileURL, err := h.bot.GetFileDirectURL(fileID)
+	if err != nil {
+		return nil, fmt.Errorf("get file url: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// В ошибке клиента есть URL с токеном бота — в лог он попасть не должен.
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return nil, fmt.Errorf("download file: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download file: status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, fmt.Errorf("file is larger than %d bytes", maxSize)
+	}
+	return data, nil
+}
+
+// describeAttachment tells the user what was extracted from the document.
+func describeAttachment(a *models.Attachment) string {
+	var parts []string
+	if n := len([]rune(a.Text)); n > 0 {
+		parts = append(parts, fmt.Sprintf("%d символов текста", n))
+	}
+	if n := len(a.Tables); n > 0 {
+		parts = append(parts, fmt.Sprintf("таблиц: %d", n))
+	}
+	text := fmt.Sprintf("Файл «%s» прочитан: %s.", a.Name, strings.Join(parts, ", "))
+	if a.Truncated {
+		text += " Документ длинный, эксперты увидят только его начало."
+	}
+	return text
+}
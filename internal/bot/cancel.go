@@ -0,0 +1,65 @@
+package bot
+
+import (
+	"BoardAI/internal/models"
+	"BoardAI/internal/queue"
+	"BoardAI/internal/state"
+	"context"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// handleCancel serves /cancel: it stops the user's analysis if one is queued or running,
+// otherwise it just leaves the current dialog step.
+func (h *Handler) handleCancel(ctx context.Context, chatID, userID int64) {
+	active, err := h.jobs.ActiveByUser(ctx, models.JobSourceTelegram, userID)
+	if err != nil {
+		log.Printf("active job lookup error: %v", err)
+	}
+	if active != nil {
+		h.cancelAnalysis(ctx, chatID, userID, active.ID)
+		return
+	}
+
+	h.updateSession(ctx, userID, func(s *state.Session) {
+		s.State = stateIdle
+		s.PendingAttachments = nil
+		s.PendingTranscript = ""
+		s.PendingIdea = ""
+		s.Interview = nil
+	})
+	h.bot.Send(tgbotapi.NewMessage(chatID, "Действие отменено."))
+}
+
+// cancelAnalysis stops the user's job. A queued job is finished right here; a running one is
+// aborted by its worker, which then reports through JobCancelled.
+func (h *Handler) cancelAnalysis(ctx context.Context, chatID, userID, jobID int64) {
+	job, err := h.jobs.RequestCancel(ctx, jobID, userID)
+	if err != nil {
+		log.Printf("cancel job error: %v", err)
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Не удалось отменить анализ. Попробуйте еще раз."))
+		return
+	}
+	if job == nil {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Этот анализ уже завершен."))
+		return
+	}
+
+	if job.Status == models.JobCancelled {
+		// Повторная попытка ждала в очереди, а ее анализ в истории все еще значится выполняющимся.
+		if job.AnalysisID != 0 {
+			if err := h.repo.Finish(ctx, job.AnalysisID, models.AnalysisCancelled, queue.ErrCancelled.Error()); err != nil {
+				log.Printf("cancel analysis error: %v", err)
+			}
+		}
+		h.finishCancelled(ctx, job)
+		return
+	}
+
+	// Если задача выполняется в другой реплике, ее воркер заметит флаг отмены в БД.
+	if !h.running.Cancel(job.ID) {
+		log.Printf("job %d: cancel requested, waiting for its worker", job.ID)
+	}
+	h.bot.Send(tgbotapi.NewMessage(chatID, "⛔ Останавливаю анализ..."))
+}
@@ -0,0 +1,192 @@
+package bot
+
+import (
+	"BoardAI/internal/models"
+	"BoardAI/internal/state"
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// compareListSize — сколько последних завершенных анализов предлагается для сравнения.
+	compareListSize = 10
+	compareMin      = 2
+	compareMax      = 4
+	// compareTimeout ограничивает ответ модератора на сравнение.
+	compareTimeout = 5 * time.Minute
+)
+
+// handleCompare serves /compare: it starts a fresh selection of analyses to compare.
+func (h *Handler) handleCompare(ctx context.Context, chatID, userID int64) {
+	h.updateSession(ctx, userID, func(s *state.Session) {
+		s.CompareIDs = nil
+	})
+	h.showCompareList(ctx, chatID, userID, nil, 0)
+}
+
+// showCompareList sends (or edits in place) the user's finished analyses with checkboxes.
+func (h *Handler) showCompareList(ctx context.Context, chatID, userID int64, selected []int64, editMessageID int) {
+	// Берем с запасом: незавершенные анализы сравнивать не с чем, они отсеиваются.
+	list, err := h.repo.ListByUser(ctx, userID, compareListSize*2, 0)
+	if err != nil {
+		log.Printf("list analyses error: %v", err)
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Не удалось получить историю анализов."))
+		return
+	}
+	var analyses []*models.Analysis
+	for _, a := range list {
+		if a.Status == models.AnalysisCompleted && len(analyses) < compareListSize {
+			analyses = append(analyses, a)
+		}
+	}
+	if len(analyses) < compareMin {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Для сравнения нужно хотя бы два завершенных анализа."))
+		return
+	}
+
+	text := fmt.Sprintf("⚖️ Отметьте от %d до %d анализов для сравнения. Выбрано: %d.", compareMin, compareMax, len(selected))
+	kb := buildCompareKeyboard(analyses, selected)
+	if editMessageID != 0 {
+		h.bot.Send(tgbotapi.NewEditMessageTextAndMarkup(chatID, editMessageID, text, kb))
+		return
+	}
+	resp := tgbotapi.NewMessage(chatID, text)
+	resp.ReplyMarkup = kb
+	h.bot.Send(resp)
+}
+
+// toggleCompare adds the analysis to the selection or removes it from there.
+func (h *Handler) toggleCompare(ctx context.Context, chatID, userID, id int64, messageID int) {
+	var selected []int64
+	full := false
+	h.updateSession(ctx, userID, func(s *state.Session) {
+		if i := slices.Index(s.CompareIDs, id); i >= 0 {
+			s.CompareIDs = slices.Delete(s.CompareIDs, i, i+1)
+		} else if len(s.CompareIDs) >= compareMax {
+			full = true
+		} else {
+			s.CompareIDs = append(s.CompareIDs, id)
+		}
+		selected = slices.Clone(s.CompareIDs)
+	})
+	if full {
+		h.bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("Сравнить можно не больше %d анализов — снимите отметку с одного из выбранных.", compareMax)))
+		return
+	}
+	h.showCompareList(ctx, chatID, userID, selected, messageID)
+}
+
+// runCompare asks the moderator to rank the selected analyses and sends the comparison.
+// The moderator works in the background so other updates are not held up.
+func (h *Handler) runCompare(ctx context.Context, chatID, userID int64, messageID int) {
+	s, err := h.sessions.Get(ctx, userID)
+	if err != nil {
+		log.Printf("get session error: %v", err)
+		return
+	}
+	if len(s.CompareIDs) < compareMin {
+		h.bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("Отметьте хотя бы %d анализа.", compareMin)))
+		return
+	}
+
+	analyses := make([]*models.Analysis, 0, len(s.CompareIDs))
+	for _, id := range s.CompareIDs {
+		a, ok := h.loadOwnAnalysis(ctx, chatID, userID, id)
+		if !ok {
+			return
+		}
+		analyses = append(analyses, a)
+	}
+
+	if _, busy := h.comparing.LoadOrStore(userID, struct{}{}); busy {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Предыдущее сравнение еще готовится, подождите."))
+		return
+	}
+	h.bot.Send(tgbotapi.NewEditMessageText(chatID, messageID, "⚖️ Модератор сравнивает анализы..."))
+
+	go func() {
+		defer h.comparing.Delete(userID)
+
+		cctx, cancel := context.WithTimeout(ctx, compareTimeout)
+		defer cancel()
+
+		c, err := h.orchestrator.Compare(cctx, analyses, h.recordUsage(ctx, 0, userID))
+		if err != nil {
+			log.Printf("compare analyses error: user=%d: %v", userID, err)
+			h.bot.Send(tgbotapi.NewEditMessageText(chatID, messageID, "⚠️ Не удалось сравнить анализы. Попробуйте еще раз: /compare"))
+			return
+		}
+		h.updateSession(ctx, userID, func(s *state.Session) {
+			s.CompareIDs = nil
+		})
+		h.bot.Request(tgbotapi.NewDeleteMessage(chatID, messageID))
+		h.sendLongText(chatID, renderComparison(analyses, c), buildMainKeyboard())
+	}()
+}
+
+// renderComparison lays the analyses out criterion by criterion and ends with the moderator's ranking.
+func renderComparison(analyses []*models.Analysis, c *models.Comparison) string {
+	var sb strings.Builder
+	sb.WriteString("⚖️ СРАВНЕНИЕ АНАЛИЗОВ\n\n")
+	for _, a := range analyses {
+		fmt.Fprintf(&sb, "#%d — %s\n", a.ID, truncateRunes(a.IdeaText, 80))
+	}
+
+	sb.WriteString("\n▪️ Вердикт\n")
+	hasVerdict := false
+	for _, a := range analyses {
+		if v := a.Verdict; v != nil {
+			hasVerdict = true
+			fmt.Fprintf(&sb, "#%d: %s, %d/100\n", a.ID, v.Decision.Title(), v.Score)
+		} else {
+			fmt.Fprintf(&sb, "#%d: без оценки\n", a.ID)
+		}
+	}
+
+	if hasVerdict {
+		sb.WriteString("\n▪️ Оценки по направлениям\n")
+		for _, d := range models.VerdictDimensions {
+			cells := make([]string, len(analyses))
+			for i, a := range analyses {
+				value := "—"
+				if a.Verdict != nil {
+					if s, ok := a.Verdict.Dimensions[d]; ok {
+						value = fmt.Sprint(s)
+					}
+				}
+				cells[i] = fmt.Sprintf("#%d %s", a.ID, value)
+			}
+			fmt.Fprintf(&sb, "%s: %s\n", models.DimensionTitle(d), strings.Join(cells, " · "))
+		}
+
+		sb.WriteString("\n▪️ Ключевые риски\n")
+		for _, a := range analyses {
+			if a.Verdict == nil || len(a.Verdict.Risks) == 0 {
+				continue
+			}
+			fmt.Fprintf(&sb, "#%d: %s\n", a.ID, strings.Join(a.Verdict.Risks, "; "))
+		}
+	}
+
+	sb.WriteString("\n▪️ Финансовые допущения\n")
+	for _, a := range analyses {
+		if e, _, ok := c.Entry(a.ID); ok && e.Finance != "" {
+			fmt.Fprintf(&sb, "#%d: %s\n", a.ID, e.Finance)
+		}
+	}
+
+	sb.WriteString("\n🏆 РЕЙТИНГ МОДЕРАТОРА\n")
+	for i, e := range c.Ranking {
+		fmt.Fprintf(&sb, "%d. #%d — %s\n", i+1, e.AnalysisID, e.Reason)
+	}
+	if c.Summary != "" {
+		fmt.Fprintf(&sb, "\n%s\n", c.Summary)
+	}
+	return sb.String()
+}
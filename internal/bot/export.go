@@ -0,0 +1,52 @@
+package bot
+
+import (
+	"BoardAI/internal/export"
+	"BoardAI/internal/models"
+	"context"
+	"fmt"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// askExportFormat lets the user pick the file format for analysis id (0 — the last one).
+func (h *Handler) askExportFormat(chatID int64, id int64) {
+	resp := tgbotapi.NewMessage(chatID, "В каком формате выгрузить отчет совета?")
+	resp.ReplyMarkup = buildExportKeyboard(id)
+	h.bot.Send(resp)
+}
+
+// sendExport renders the analysis into a board report file and sends it as a document.
+func (h *Handler) sendExport(ctx context.Context, chatID, userID, id int64, format string) {
+	f, err := export.ParseFormat(format)
+	if err != nil {
+		return
+	}
+
+	var a *models.Analysis
+	if id == 0 {
+		if _, a = h.lastAnalysis(ctx, chatID, userID); a == nil {
+			return
+		}
+	} else {
+		var ok bool
+		if a, ok = h.loadOwnAnalysis(ctx, chatID, userID, id); !ok {
+			return
+		}
+	}
+
+	data, err := h.exporter.Render(a, h.board, f)
+	if err != nil {
+		log.Printf("export analysis error: %v", err)
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Не удалось сформировать отчет."))
+		return
+	}
+
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: export.FileName(a, f), Bytes: data})
+	doc.Caption = fmt.Sprintf("📄 Отчет совета директоров (%s)", f.Title())
+	if _, err := h.bot.Send(doc); err != nil {
+		log.Printf("send export error: %v", err)
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Не удалось отправить файл отчета."))
+	}
+}
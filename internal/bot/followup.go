@@ -0,0 +1,149 @@
+package bot
+
+import (
+	"BoardAI/internal/models"
+	"BoardAI/internal/state"
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// followUpHistoryLimit — сколько последних реплик разговора загружается; что не влезет в контекст
+	// модели, оркестратор отбросит сам.
+	followUpHistoryLimit = 40
+	// followUpTimeout ограничивает ответ эксперта на один вопрос.
+	followUpTimeout = 5 * time.Minute
+)
+
+// chooseExpert offers the experts of analysis id to ask a question.
+func (h *Handler) chooseExpert(ctx context.Context, chatID, userID, id int64) {
+	a, ok := h.loadFollowUpAnalysis(ctx, chatID, userID, id)
+	if !ok {
+		return
+	}
+	resp := tgbotapi.NewMessage(chatID, fmt.Sprintf("Кому из экспертов задать вопрос по анализу #%d?", a.ID))
+	resp.ReplyMarkup = buildExpertsKeyboard(h.board, a, callbackAskExpertAs)
+	h.bot.Send(resp)
+}
+
+// startFollowUp switches the user into a conversation with role about analysis id.
+func (h *Handler) startFollowUp(ctx context.Context, chatID, userID, id int64, role string) {
+	a, ok := h.loadFollowUpAnalysis(ctx, chatID, userID, id)
+	if !ok {
+		return
+	}
+	if _, ok := a.Report(role); !ok {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "У этого эксперта нет отчета по анализу."))
+		return
+	}
+
+	h.updateSession(ctx, userID, func(s *state.Session) {
+		s.State = stateFollowUp
+		s.FollowUpAnalysisID = a.ID
+		s.FollowUpRole = role
+	})
+
+	text := fmt.Sprintf("%s слушает. Задайте вопрос по анализу #%d — эксперт ответит, опираясь на свой отчет.",
+		h.board.Title(role), a.ID)
+	thread, err := h.followups.Thread(ctx, a.ID, role, followUpHistoryLimit)
+	if err != nil {
+		log.Printf("follow-up thread error: %v", err)
+	} else if len(thread) > 0 {
+		text += fmt.Sprintf("\n\nРазговор продолжается: эксперт помнит предыдущие %d сообщений.", len(thread))
+	}
+	resp := tgbotapi.NewMessage(chatID, text)
+	resp.ReplyMarkup = buildFollowUpKeyboard(a.ID)
+	h.bot.Send(resp)
+}
+
+// askFollowUp passes the user's message to the expert they are talking to. The answer takes
+// a while, so it is produced in the background; a user has at most one question in flight.
+func (h *Handler) askFollowUp(ctx context.Context, msg *tgbotapi.Message) {
+	chatID, userID := msg.Chat.ID, msg.From.ID
+
+	s, err := h.sessions.Get(ctx, userID)
+	if err != nil || s.FollowUpAnalysisID == 0 || s.FollowUpRole == "" {
+		if err != nil {
+			log.Printf("get session error: %v", err)
+		}
+		h.finishFollowUp(ctx, chatID, userID)
+		return
+	}
+	a, ok := h.loadFollowUpAnalysis(ctx, chatID, userID, s.FollowUpAnalysisID)
+	if !ok {
+		h.setState(ctx, userID, stateIdle)
+		return
+	}
+	role := s.FollowUpRole
+
+	if _, busy := h.answering.LoadOrStore(userID, struct{}{}); busy {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Эксперт еще отвечает на предыдущий вопрос, подождите."))
+		return
+	}
+
+	thread, err := h.followups.Thread(ctx, a.ID, role, followUpHistoryLimit)
+	if err != nil {
+		log.Printf("follow-up thread error: %v", err)
+	}
+
+	h.bot.Request(tgbotapi.NewChatAction(chatID, tgbotapi.ChatTyping))
+	sent, _ := h.bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("✍️ %s думает над ответом...", h.board.Title(role))))
+
+	go func() {
+		defer h.answering.Delete(userID)
+
+		actx, cancel := context.WithTimeout(ctx, followUpTimeout)
+		defer cancel()
+
+		answer, err := h.orchestrator.FollowUp(actx, a, role, thread, msg.Text, h.recordUsage(ctx, a.ID, userID))
+		if err != nil {
+			log.Printf("follow-up error: analysis=%d role=%s: %v", a.ID, role, err)
+			h.bot.Send(tgbotapi.NewEditMessageText(chatID, sent.MessageID, "⚠️ Эксперт не смог ответить. Попробуйте переформулировать вопрос."))
+			return
+		}
+
+		// Вопрос и ответ сохраняются вместе: неотвеченный вопрос только запутал бы модель в следующий раз.
+		for _, m := range []*models.FollowUpMessage{
+			{AnalysisID: a.ID, UserID: userID, Role: role, Author: models.FollowUpFromUser, Content: msg.Text},
+			{AnalysisID: a.ID, UserID: userID, Role: role, Author: models.FollowUpFromExpert, Content: answer},
+		} {
+			if err := h.followups.Add(ctx, m); err != nil {
+				log.Printf("save follow-up error: %v", err)
+			}
+		}
+
+		h.bot.Request(tgbotapi.NewDeleteMessage(chatID, sent.MessageID))
+		h.sendLongText(chatID, fmt.Sprintf("%s:\n\n%s", h.board.Title(role), answer), buildFollowUpKeyboard(a.ID))
+	}()
+}
+
+// finishFollowUp leaves the conversation with the expert; the history stays with the analysis.
+func (h *Handler) finishFollowUp(ctx context.Context, chatID, userID int64) {
+	h.updateSession(ctx, userID, func(s *state.Session) {
+		if s.State == stateFollowUp {
+			s.State = stateIdle
+		}
+		s.FollowUpAnalysisID = 0
+		s.FollowUpRole = ""
+	})
+	resp := tgbotapi.NewMessage(chatID, "Разговор с экспертом завершен. Чтобы продолжить его позже, откройте анализ и нажмите «Спросить эксперта».")
+	resp.ReplyMarkup = buildMainKeyboard()
+	h.bot.Send(resp)
+}
+
+// loadFollowUpAnalysis loads the user's analysis and checks the experts can be asked about it.
+func (h *Handler) loadFollowUpAnalysis(ctx context.Context, chatID, userID, id int64) (*models.Analysis, bool) {
+	a, ok := h.loadOwnAnalysis(ctx, chatID, userID, id)
+	if !ok {
+		return nil, false
+	}
+	if a.Status != models.AnalysisCompleted || len(a.Reports) == 0 {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Вопросы можно задавать только по завершенному анализу."))
+		return nil, false
+	}
+	return a, true
+}
@@ -1,73 +1,114 @@
 package bot
 
 import (
+	"BoardAI/internal/board"
+	"BoardAI/internal/export"
 	"BoardAI/internal/models"
 	"BoardAI/internal/orchestrator"
+	"BoardAI/internal/queue"
+	"BoardAI/internal/quota"
 	"BoardAI/internal/repository"
+	"BoardAI/internal/speech"
+	"BoardAI/internal/state"
 	"context"
+	"fmt"
 	"log"
+	"strings"
 	"sync"
-	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
 const (
-	stateIdle         = "IDLE"
+	stateIdle         = state.Idle
 	stateWaitingQuery = "STATE_WAITING_QUERY"
 	stateProcessing   = "STATE_PROCESSING"
 	stateLastAnalysis = "STATE_LAST_ANALYSIS"
+	// stateFollowUp — пользователь задает вопросы эксперту по готовому анализу.
+	stateFollowUp = "STATE_FOLLOW_UP"
+	// stateConfirmVoice — расшифровка голосового ждет подтверждения или исправленного текста.
+	stateConfirmVoice = "STATE_CONFIRM_VOICE"
+	// stateInterview — автор короткой идеи отвечает на уточняющие вопросы.
+	stateInterview = "STATE_INTERVIEW"
 )
 
-type StateManager struct {
-	mu    sync.RWMutex
-	state map[int64]string
-}
-
-func NewStateManager() *StateManager {
-	return &StateManager{
-		state: make(map[int64]string),
-	}
-}
-
-func (s *StateManager) Get(userID int64) string {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	if v, ok := s.state[userID]; ok {
-		return v
-	}
-	return stateIdle
-}
-
-func (s *StateManager) Set(userID int64, st string) {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	s.state[userID] = st
-}
-
 type Handler struct {
 	bot          *tgbotapi.BotAPI
 	repo         repository.AnalysisRepository
+	jobs         repository.JobRepository
+	usage        repository.UsageRepository
+	followups    repository.FollowUpRepository
+	maxAttempts  int
 	orchestrator *orchestrator.Orchestrator
-	state        *StateManager
-	lastAnalysis sync.Map
+	exporter     *export.Exporter
+	board        *board.Board
+	sessions     state.Store
+	running      *queue.Registry
+	quota        *quota.Limiter
+	// interviewBelow — идеи короче стольких символов сначала уточняются вопросами; 0 — без интервью.
+	interviewBelow int
+	// transcriber распознает голосовые сообщения; nil — голосовые не принимаются.
+	transcriber speech.Transcriber
+	// isAdmin открывает отчеты по всем пользователям.
+	isAdmin func(userID int64) bool
+	// answering отмечает пользователей, чей вопрос эксперту еще обрабатывается.
+	answering sync.Map
+	// transcribing отмечает пользователей, чье голосовое еще распознается.
+	transcribing sync.Map
+	// interviewing отмечает пользователей, для которых готовятся вопросы или бриф.
+	interviewing sync.Map
+	// comparing отмечает пользователей, для которых модератор сравнивает анализы.
+	comparing sync.Map
+	// reading считает документы пользователя, которые еще скачиваются и разбираются.
+	reading sync.Map
+	// rerunning отмечает пользователей, для которых заново готовится отчет эксперта или вердикт.
+	rerunning sync.Map
+	// live хранит прогресс-сообщения выполняющихся задач по id задачи.
+	live sync.Map
 }
 
 func NewHandler(
 	bot *tgbotapi.BotAPI,
 	repo repository.AnalysisRepository,
+	jobs repository.JobRepository,
+	usage repository.UsageRepository,
+	followups repository.FollowUpRepository,
+	maxAttempts int,
 	orc *orchestrator.Orchestrator,
-	state *StateManager,
+	exporter *export.Exporter,
+	sessions state.Store,
+	running *queue.Registry,
+	limiter *quota.Limiter,
+	interviewBelow int,
+	transcriber speech.Transcriber,
+	isAdmin func(userID int64) bool,
 ) *Handler {
 	return &Handler{
-		bot:          bot,
-		repo:         repo,
-		orchestrator: orc,
-		state:        state,
+		bot:            bot,
+		repo:           repo,
+		jobs:           jobs,
+		usage:          usage,
+		followups:      followups,
+		maxAttempts:    maxAttempts,
+		orchestrator:   orc,
+		exporter:       exporter,
+		board:          orc.Board(),
+		sessions:       sessions,
+		running:        running,
+		quota:          limiter,
+		interviewBelow: interviewBelow,
+		transcriber:    transcriber,
+		isAdmin:        isAdmin,
 	}
 }
 
+// Run receives updates with long polling.
 func (h *Handler) Run(ctx context.Context) error {
+	// Пока зарегистрирован webhook, getUpdates не работает: снимаем его, не теряя накопленные обновления.
+	if _, err := h.bot.Request(tgbotapi.DeleteWebhookConfig{}); err != nil {
+		log.Printf("delete webhook error: %v", err)
+	}
+
 	u := tgbotapi.NewUpdate(0)
 	u.Timeout = 600
 	updates := h.bot.GetUpdatesChan(u)
@@ -103,10 +144,19 @@ func (h *Handler) handleMessage(ctx context.Context, msg *tgbotapi.Message) {
 
 	switch msg.Text {
 	case "Новый анализ", "🔄 Новый анализ":
-		h.askForIdea(msg)
+		h.askForIdea(ctx, msg.Chat.ID, userID)
 		return
 	case "Мои анализы", "📋 Мои анализы":
-		h.showHistory(ctx, msg)
+		h.showHistory(ctx, msg.Chat.ID, userID, 0, 0)
+		return
+	}
+
+	if msg.Document != nil {
+		h.handleDocument(ctx, msg)
+		return
+	}
+	if msg.Voice != nil || msg.Audio != nil {
+		h.handleVoice(ctx, msg)
 		return
 	}
 
@@ -115,31 +165,52 @@ func (h *Handler) handleMessage(ctx context.Context, msg *tgbotapi.Message) {
 		case "start":
 			h.handleStart(msg)
 		case "new":
-			h.askForIdea(msg)
+			h.askForIdea(ctx, msg.Chat.ID, userID)
 		case "list":
-			h.showHistory(ctx, msg)
+			h.showHistory(ctx, msg.Chat.ID, userID, 0, 0)
 		case "cancel":
-			h.state.Set(userID, stateIdle)
-			h.bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Действие отменено."))
+			h.handleCancel(ctx, msg.Chat.ID, userID)
+		case "usage":
+			h.handleUsage(ctx, msg.Chat.ID, userID)
+		case "usage_report":
+			h.handleUsageReport(ctx, msg.Chat.ID, userID)
+		case "compare":
+			h.handleCompare(ctx, msg.Chat.ID, userID)
 		default:
 			h.bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Неизвестная команда. /new - новый анализ."))
 		}
 		return
 	}
 
-	state := h.state.Get(userID)
-	if state == stateWaitingQuery {
-		h.processIdea(ctx, msg)
-	} else {
+	switch h.userState(ctx, userID) {
+	case stateWaitingQuery, stateConfirmVoice:
+		// В ответ на расшифровку голосового пользователь присылает исправленный текст идеи.
+		h.submitIdea(ctx, msg.Chat.ID, userID, msg.Text)
+	case stateInterview:
+		if answer := strings.TrimSpace(msg.Text); answer != "" {
+			h.answerInterview(ctx, msg.Chat.ID, userID, answer)
+		} else {
+			h.bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Ответьте на вопрос текстом или нажмите «⏭ Пропустить»."))
+		}
+	case stateFollowUp:
+		h.askFollowUp(ctx, msg)
+	default:
 		resp := tgbotapi.NewMessage(msg.Chat.ID, "Нажмите кнопку «Новый анализ», чтобы начать.")
 		resp.ReplyMarkup = buildMainKeyboard()
 		h.bot.Send(resp)
 	}
 }
 
-func (h *Handler) askForIdea(msg *tgbotapi.Message) {
-	h.state.Set(msg.From.ID, stateWaitingQuery)
-	resp := tgbotapi.NewMessage(msg.Chat.ID, "Опишите бизнес-идею подробно. Я запущу экспертный совет (займет 3-5 мин).")
+// askForIdea takes chat and user ids explicitly: for a button press the message author is
+// the bot itself, not the user who pressed it.
+func (h *Handler) askForIdea(ctx context.Context, chatID, userID int64) {
+	// Квоту проверяем заранее, чтобы пользователь не писал идею впустую.
+	if _, ok := h.checkQuota(ctx, chatID, userID); !ok {
+		return
+	}
+	h.setState(ctx, userID, stateWaitingQuery)
+	resp := tgbotapi.NewMessage(chatID, "Опишите бизнес-идею подробно. Я запущу экспертный совет (займет 3-5 мин).\n\n"+
+		"Можно приложить презентацию или финансовую модель: PDF, DOCX, XLSX, CSV или TXT, а идею — надиктовать голосовым.")
 	resp.ReplyMarkup = tgbotapi.NewRemoveKeyboard(true)
 	h.bot.Send(resp)
 }
@@ -151,128 +222,201 @@ func (h *Handler) handleStart(msg *tgbotapi.Message) {
 	h.bot.Send(resp)
 }
 
-func (h *Handler) processIdea(ctx context.Context, msg *tgbotapi.Message) {
-	userID := msg.From.ID
-	idea := msg.Text
-
-	if h.state.Get(userID) == stateProcessing {
-		h.bot.Send(tgbotapi.NewMessage(msg.Chat.ID, "Анализ уже идет, пожалуйста, подождите."))
+// processIdea queues the analysis of the idea together with the documents the user sent before it
+// and the brief from the clarification interview, if there was one.
+func (h *Handler) processIdea(ctx context.Context, chatID, userID int64, idea, brief string) {
+	// Сессия могла истечь или жить только в памяти, поэтому квоты считаем по очереди в БД.
+	st, ok := h.checkQuota(ctx, chatID, userID)
+	if !ok {
+		h.setState(ctx, userID, stateIdle)
 		return
 	}
 
-	h.state.Set(userID, stateProcessing)
-	waitMsg := tgbotapi.NewMessage(msg.Chat.ID, "⏳ Анализ запущен. Я пришлю результат, как только эксперты закончат...")
-	sent, _ := h.bot.Send(waitMsg)
-
-	go func() {
-		analysisCtx, cancel := context.WithTimeout(context.Background(), 20*time.Minute)
-		defer cancel()
-
-		log.Printf("DEBUG: Starting RunAnalysis for user %d", userID)
-		analysis, err := h.orchestrator.RunAnalysis(analysisCtx, idea, int64(userID))
-
-		if err != nil {
-			log.Printf("RunAnalysis error: %v", err)
-			h.state.Set(userID, stateIdle)
-			h.bot.Send(tgbotapi.NewEditMessageText(sent.Chat.ID, sent.MessageID, "⚠️ Ошибка анализа. Попробуйте позже."))
-			return
-		}
-
-		h.lastAnalysis.Store(int64(userID), analysis)
-		h.state.Set(userID, stateLastAnalysis)
-
-		fullText := renderAnalysisMarkdown(analysis)
-
-		log.Printf("DEBUG: Analysis complete, text length: %d", len(fullText))
+	var attachments []models.Attachment
+	if s, err := h.sessions.Get(ctx, userID); err != nil {
+		log.Printf("get session error: %v", err)
+	} else {
+		attachments = s.PendingAttachments
+	}
 
-		if len(fullText) < 4000 {
-			edit := tgbotapi.NewEditMessageText(sent.Chat.ID, sent.MessageID, fullText)
+	h.setState(ctx, userID, stateProcessing)
+	waitText := "⏳ Анализ поставлен в очередь. Я пришлю результат, как только эксперты закончат..."
+	if len(attachments) > 0 {
+		waitText += fmt.Sprintf("\nПриложено документов: %d.", len(attachments))
+	}
+	if left := renderQuotaLeft(st, 1); left != "" {
+		waitText += "\n\n" + left
+	}
+	waitMsg := tgbotapi.NewMessage(chatID, waitText)
+	sent, _ := h.bot.Send(waitMsg)
 
-			edit.ParseMode = "HTML"
-			edit.ReplyMarkup = buildMainKeyboard()
-			h.bot.Send(edit)
-		} else {
-			h.bot.Send(tgbotapi.NewDeleteMessage(sent.Chat.ID, sent.MessageID))
-
-			for i := 0; i < len(fullText); i += 3900 {
-				end := i + 3900
-				if end > len(fullText) {
-					end = len(fullText)
-				}
-				part := fullText[i:end]
-				msg := tgbotapi.NewMessage(sent.Chat.ID, part)
-				if i+3900 >= len(fullText) {
-					msg.ReplyMarkup = buildMainKeyboard()
-				}
-				h.bot.Send(msg)
-			}
-		}
-	}()
+	job := &models.Job{
+		Source:      models.JobSourceTelegram,
+		UserID:      userID,
+		ChatID:      chatID,
+		MessageID:   sent.MessageID,
+		IdeaText:    idea,
+		Brief:       brief,
+		Attachments: attachments,
+		MaxAttempts: h.maxAttempts,
+	}
+	if err := h.jobs.Enqueue(ctx, job); err != nil {
+		log.Printf("enqueue job error: %v", err)
+		h.setState(ctx, userID, stateIdle)
+		h.bot.Send(tgbotapi.NewEditMessageText(chatID, sent.MessageID, "⚠️ Не удалось поставить анализ в очередь. Попробуйте позже."))
+		return
+	}
+	h.updateSession(ctx, userID, func(s *state.Session) {
+		s.PendingJobID = job.ID
+		s.PendingAttachments = nil
+		s.PendingTranscript = ""
+		s.PendingIdea = ""
+		s.Interview = nil
+	})
+	h.bot.Send(tgbotapi.NewEditMessageReplyMarkup(chatID, sent.MessageID, buildCancelKeyboard(job.ID)))
+
+	log.Printf("job %d queued for user %d", job.ID, userID)
 }
 
 func (h *Handler) handleCallbackQuery(ctx context.Context, cq *tgbotapi.CallbackQuery) {
+	chatID := cq.Message.Chat.ID
+	userID := cq.From.ID
+
 	switch cq.Data {
 	case callbackNewAnalysis:
-		h.askForIdea(cq.Message)
+		h.askForIdea(ctx, chatID, userID)
 	case callbackSaveAnalysis:
-		h.saveLastAnalysis(ctx, cq)
+		h.pinLastAnalysis(ctx, cq)
 	case callbackListHistory:
-		h.showHistory(ctx, cq.Message)
+		h.showHistory(ctx, chatID, userID, 0, 0)
+	case callbackShowDebate:
+		h.showDebate(ctx, chatID, userID)
+	case callbackAskDone:
+		h.finishFollowUp(ctx, chatID, userID)
+	case callbackVoiceConfirm:
+		h.confirmTranscript(ctx, chatID, userID, cq.Message.MessageID)
+	case callbackVoiceCancel:
+		h.discardTranscript(ctx, chatID, userID, cq.Message.MessageID)
+	case callbackInterviewSkip:
+		h.bot.Send(tgbotapi.NewEditMessageReplyMarkup(chatID, cq.Message.MessageID, emptyInlineKeyboard()))
+		h.answerInterview(ctx, chatID, userID, "")
+	case callbackCompare:
+		h.handleCompare(ctx, chatID, userID)
+	case callbackCompareRun:
+		h.runCompare(ctx, chatID, userID, cq.Message.MessageID)
+	case callbackInterviewRun:
+		h.bot.Send(tgbotapi.NewEditMessageReplyMarkup(chatID, cq.Message.MessageID, emptyInlineKeyboard()))
+		h.finishInterview(ctx, chatID, userID)
 	default:
+		h.handleCallbackWithArg(ctx, cq)
 	}
 
 	_, _ = h.bot.Request(tgbotapi.NewCallback(cq.ID, ""))
 }
 
-func (h *Handler) saveLastAnalysis(ctx context.Context, cq *tgbotapi.CallbackQuery) {
-	val, ok := h.lastAnalysis.Load(int64(cq.From.ID))
+func (h *Handler) handleCallbackWithArg(ctx context.Context, cq *tgbotapi.CallbackQuery) {
+	prefix, arg, ok := parseCallback(cq.Data)
 	if !ok {
-		resp := tgbotapi.NewMessage(cq.Message.Chat.ID, "Нет последнего анализа для сохранения\\. Сначала запусти анализ\\.")
-		h.bot.Send(resp)
 		return
 	}
 
-	analysis, ok := val.(*models.Analysis)
-	if !ok || analysis == nil {
-		return
+	chatID := cq.Message.Chat.ID
+	userID := cq.From.ID
+
+	switch prefix {
+	case callbackHistoryPage:
+		h.showHistory(ctx, chatID, userID, int(arg), cq.Message.MessageID)
+	case callbackHistoryOpen:
+		h.openAnalysis(ctx, chatID, userID, arg)
+	case callbackHistoryDelete:
+		h.confirmDeleteAnalysis(ctx, chatID, userID, arg)
+	case callbackDeleteConfirm:
+		h.deleteAnalysis(ctx, chatID, userID, arg, cq.Message.MessageID)
+	case callbackShowDebate:
+		if a, ok := h.loadOwnAnalysis(ctx, chatID, userID, arg); ok {
+			h.sendDebate(chatID, a)
+		}
+	case callbackExport:
+		h.askExportFormat(chatID, arg)
+	case callbackCancelJob:
+		h.cancelAnalysis(ctx, chatID, userID, arg)
+	case callbackPin:
+		h.setPinned(ctx, cq, arg, true)
+	case callbackUnpin:
+		h.setPinned(ctx, cq, arg, false)
+	case callbackAskExpert:
+		h.chooseExpert(ctx, chatID, userID, arg)
+	case callbackComparePick:
+		h.toggleCompare(ctx, chatID, userID, arg, cq.Message.MessageID)
+	case callbackRerun:
+		h.chooseRerunExpert(ctx, chatID, userID, arg)
+	case callbackReverdict:
+		h.regenerateVerdict(ctx, chatID, userID, arg)
+	case callbackRevisions:
+		h.showRevisions(ctx, chatID, userID, arg)
+	default:
+		if format, ok := strings.CutPrefix(prefix, callbackExportAs); ok {
+			h.sendExport(ctx, chatID, userID, arg, format)
+		} else if role, ok := strings.CutPrefix(prefix, callbackAskExpertAs); ok {
+			h.startFollowUp(ctx, chatID, userID, arg, role)
+		} else if role, ok := strings.CutPrefix(prefix, callbackRerunAs); ok {
+			h.chooseRerunModel(ctx, chatID, userID, arg, role)
+		} else if rest, ok := strings.CutPrefix(prefix, callbackRerunWith); ok {
+			if n, role, ok := parseRerunWith(rest); ok {
+				h.rerunExpert(ctx, chatID, userID, arg, role, n)
+			}
+		}
 	}
+}
 
-	if err := h.repo.Create(ctx, analysis); err != nil {
-		log.Printf("save analysis error: %v", err)
-		resp := tgbotapi.NewMessage(cq.Message.Chat.ID, "Не удалось сохранить анализ в базу данных\\.")
-		h.bot.Send(resp)
-		return
+// sendLongText splits text into several messages below the Telegram limit; markup goes to the last one.
+func (h *Handler) sendLongText(chatID int64, text string, markup interface{}) {
+	parts := splitMessage(text, 3900)
+	for i, part := range parts {
+		msg := tgbotapi.NewMessage(chatID, part)
+		if i == len(parts)-1 && markup != nil {
+			msg.ReplyMarkup = markup
+		}
+		h.bot.Send(msg)
 	}
+}
 
-	resp := tgbotapi.NewMessage(cq.Message.Chat.ID, "Анализ успешно сохранен в базу данных ✅")
-	h.bot.Send(resp)
+func (h *Handler) showDebate(ctx context.Context, chatID, userID int64) {
+	if _, analysis := h.lastAnalysis(ctx, chatID, userID); analysis != nil {
+		h.sendDebate(chatID, analysis)
+	}
 }
 
-func (h *Handler) showHistory(ctx context.Context, msg *tgbotapi.Message) {
-	analyses, err := h.repo.List(ctx, 5, 0)
-	if err != nil {
-		log.Printf("list analyses error: %v", err)
-		resp := tgbotapi.NewMessage(msg.Chat.ID, "Не удалось получить историю анализов\\.")
-		h.bot.Send(resp)
+func (h *Handler) sendDebate(chatID int64, analysis *models.Analysis) {
+	if len(analysis.Rounds) == 0 {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Для этого анализа дебаты не проводились."))
 		return
 	}
+	h.sendLongText(chatID, renderDebate(analysis, h.board), buildMainKeyboard())
+}
 
-	if len(analyses) == 0 {
-		resp := tgbotapi.NewMessage(msg.Chat.ID, "История пуста\\. Сначала проведи анализ новой идеи\\.")
-		h.bot.Send(resp)
+// pinLastAnalysis serves the "Сохранить" button of messages sent before every run was recorded
+// automatically: it adds the last analysis to the favourites, saving it first if needed.
+func (h *Handler) pinLastAnalysis(ctx context.Context, cq *tgbotapi.CallbackQuery) {
+	chatID := cq.Message.Chat.ID
+	job, analysis := h.lastAnalysis(ctx, chatID, cq.From.ID)
+	if analysis == nil {
+		return
+	}
+	if job.AnalysisID != 0 {
+		h.setPinned(ctx, cq, job.AnalysisID, true)
 		return
 	}
 
-	var text string
-	text = "*Последние анализы:*\n\n"
-	for _, a := range analyses {
-		idea := a.IdeaText
-		if len(idea) > 80 {
-			idea = idea[:80] + "..."
-		}
-		text += "• " + escapeMarkdownV2(idea) + "\n"
+	analysis.Pinned = true
+	if err := h.repo.Create(ctx, analysis); err != nil {
+		log.Printf("save analysis error: %v", err)
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Не удалось сохранить анализ в базу данных."))
+		return
+	}
+	if err := h.jobs.SetAnalysis(ctx, job.ID, analysis.ID); err != nil {
+		log.Printf("link saved analysis error: %v", err)
 	}
 
-	resp := tgbotapi.NewMessage(msg.Chat.ID, text)
-	h.bot.Send(resp)
+	h.bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("Анализ #%d добавлен в избранное ⭐", analysis.ID)))
 }
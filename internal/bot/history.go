@@ -0,0 +1,240 @@
+package bot
+
+import (
+	"BoardAI/internal/models"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const historyPageSize = 5
+
+// showHistory sends (or, when editMessageID is set, edits in place) one page of the user's analyses.
+func (h *Handler) showHistory(ctx context.Context, chatID, userID int64, page, editMessageID int) {
+	if page < 0 {
+		page = 0
+	}
+
+	// Запрашиваем на одну запись больше, чтобы понять, есть ли следующая страница.
+	analyses, err := h.repo.ListByUser(ctx, userID, historyPageSize+1, page*historyPageSize)
+	if err != nil {
+		log.Printf("list analyses error: %v", err)
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Не удалось получить историю анализов."))
+		return
+	}
+
+	if len(analyses) == 0 && page == 0 {
+		resp := tgbotapi.NewMessage(chatID, "История пуста. Сначала проведи анализ новой идеи.")
+		resp.ReplyMarkup = buildMainKeyboard()
+		h.bot.Send(resp)
+		return
+	}
+
+	hasNext := len(analyses) > historyPageSize
+	if hasNext {
+		analyses = analyses[:historyPageSize]
+	}
+
+	text := renderHistoryPage(analyses, page)
+	kb := buildHistoryKeyboard(analyses, page, hasNext)
+
+	if editMessageID != 0 {
+		edit := tgbotapi.NewEditMessageTextAndMarkup(chatID, editMessageID, text, kb)
+		h.bot.Send(edit)
+		return
+	}
+
+	resp := tgbotapi.NewMessage(chatID, text)
+	resp.ReplyMarkup = kb
+	h.bot.Send(resp)
+}
+
+// openAnalysis shows a stored analysis in full; only the owner may open it.
+func (h *Handler) openAnalysis(ctx context.Context, chatID, userID, id int64) {
+	a, ok := h.loadOwnAnalysis(ctx, chatID, userID, id)
+	if !ok {
+		return
+	}
+
+	text := fmt.Sprintf("🗂 Анализ #%d от %s\n", a.ID, a.CreatedAt.Format("02.01.2006 15:04"))
+	if a.Pinned {
+		text += "⭐ В избранном\n"
+	}
+	if len(a.Attachments) > 0 {
+		names := make([]string, len(a.Attachments))
+		for i, att := range a.Attachments {
+			names[i] = att.Name
+		}
+		text += "📎 " + strings.Join(names, ", ") + "\n"
+	}
+	if a.Status != models.AnalysisCompleted {
+		text += renderRunStatus(a)
+		h.sendLongText(chatID, text, buildStoredAnalysisKeyboard(a))
+		return
+	}
+	if d := a.Duration(); d > 0 {
+		text += fmt.Sprintf("⏱ Совет работал %s\n", formatDuration(d))
+	}
+	if a.PromptTokens+a.CompletionTokens > 0 {
+		text += fmt.Sprintf("🔢 %s ток., $%.4f\n", formatTokens(int64(a.PromptTokens+a.CompletionTokens)), a.CostUSD)
+	}
+	text += "\n" + renderAnalysisMarkdown(a, h.board)
+	h.sendLongText(chatID, text, buildStoredAnalysisKeyboard(a))
+}
+
+// setPinned adds the analysis to the favourites or removes it and flips the button on the message.
+func (h *Handler) setPinned(ctx context.Context, cq *tgbotapi.CallbackQuery, id int64, pinned bool) {
+	chatID := cq.Message.Chat.ID
+	found, err := h.repo.SetPinned(ctx, id, cq.From.ID, pinned)
+	if err != nil {
+		log.Printf("pin analysis error: %v", err)
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Не удалось обновить избранное."))
+		return
+	}
+	if !found {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Анализ не найден."))
+		return
+	}
+
+	if kb := cq.Message.ReplyMarkup; replacePinButton(kb, id, pinned) {
+		h.bot.Send(tgbotapi.NewEditMessageReplyMarkup(chatID, cq.Message.MessageID, *kb))
+		return
+	}
+	text := fmt.Sprintf("Анализ #%d добавлен в избранное ⭐", id)
+	if !pinned {
+		text = fmt.Sprintf("Анализ #%d убран из избранного.", id)
+	}
+	h.bot.Send(tgbotapi.NewMessage(chatID, text))
+}
+
+func (h *Handler) confirmDeleteAnalysis(ctx context.Context, chatID, userID, id int64) {
+	a, ok := h.loadOwnAnalysis(ctx, chatID, userID, id)
+	if !ok {
+		return
+	}
+
+	resp := tgbotapi.NewMessage(chatID, fmt.Sprintf("Удалить анализ #%d «%s»? Это действие нельзя отменить.", a.ID, truncateRunes(a.IdeaText, 60)))
+	resp.ReplyMarkup = buildDeleteConfirmKeyboard(a.ID)
+	h.bot.Send(resp)
+}
+
+func (h *Handler) deleteAnalysis(ctx context.Context, chatID, userID, id int64, messageID int) {
+	deleted, err := h.repo.Delete(ctx, id, userID)
+	if err != nil {
+		log.Printf("delete analysis error: %v", err)
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Не удалось удалить анализ."))
+		return
+	}
+	if !deleted {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Анализ не найден."))
+		return
+	}
+
+	edit := tgbotapi.NewEditMessageText(chatID, messageID, fmt.Sprintf("🗑 Анализ #%d удален.", id))
+	h.bot.Send(edit)
+	h.showHistory(ctx, chatID, userID, 0, 0)
+}
+
+// loadOwnAnalysis fetches an analysis and reports to the chat when it is missing or belongs to someone else.
+func (h *Handler) loadOwnAnalysis(ctx context.Context, chatID, userID, id int64) (*models.Analysis, bool) {
+	a, err := h.repo.Get(ctx, id)
+	if err != nil {
+		log.Printf("get analysis error: %v", err)
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Не удалось загрузить анализ."))
+		return nil, false
+	}
+	// Чужие анализы выглядят так же, как несуществующие.
+	if a == nil || a.UserID != userID {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Анализ не найден."))
+		return nil, false
+	}
+	return a, true
+}
+
+func renderHistoryPage(analyses []*models.Analysis, page int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "📜 Ваши анализы (страница %d):\n", page+1)
+	for i, a := range analyses {
+		fmt.Fprintf(&sb, "\n%d. %s #%d от %s%s\n%s\n", page*historyPageSize+i+1, historyIcon(a), a.ID,
+			a.CreatedAt.Format("02.01.2006"), statusSuffix(a.Status), truncateRunes(a.IdeaText, 120))
+	}
+	sb.WriteString("\nНажмите на анализ, чтобы открыть его целиком.")
+	return sb.String()
+}
+
+// historyIcon marks favourites and runs that did not complete.
+func historyIcon(a *models.Analysis) string {
+	switch {
+	case a.Pinned:
+		return "⭐"
+	case a.Status == models.AnalysisRunning:
+		return "⏳"
+	case a.Status == models.AnalysisFailed:
+		return "❌"
+	case a.Status == models.AnalysisCancelled:
+		return "⛔"
+	}
+	return "📄"
+}
+
+func statusSuffix(s models.AnalysisStatus) string {
+	switch s {
+	case models.AnalysisRunning:
+		return " — в работе"
+	case models.AnalysisFailed:
+		return " — ошибка"
+	case models.AnalysisCancelled:
+		return " — отменен"
+	}
+	return ""
+}
+
+// renderRunStatus describes an analysis that has no result: which agents managed to finish and why it stopped.
+func renderRunStatus(a *models.Analysis) string {
+	var sb strings.Builder
+	switch a.Status {
+	case models.AnalysisRunning:
+		sb.WriteString("⏳ Анализ еще выполняется.\n")
+	case models.AnalysisFailed:
+		sb.WriteString("❌ Анализ завершился ошибкой.\n")
+	case models.AnalysisCancelled:
+		sb.WriteString("⛔ Анализ был отменен.\n")
+	}
+	if a.Error != "" {
+		fmt.Fprintf(&sb, "Причина: %s\n", a.Error)
+	}
+	fmt.Fprintf(&sb, "\n💡 ИДЕЯ: %s\n", a.IdeaText)
+	if len(a.AgentRuns) > 0 {
+		sb.WriteString("\nЭтапы:\n")
+		for _, r := range a.AgentRuns {
+			mark := "✅"
+			if r.Error != "" {
+				mark = "⚠️"
+			}
+			fmt.Fprintf(&sb, "%s %s (%s) — %s\n", mark, r.Role, r.Stage, formatDuration(r.Duration()))
+		}
+	}
+	return sb.String()
+}
+
+// formatDuration округляет длительность до секунд: миллисекунды пользователю не нужны.
+func formatDuration(d time.Duration) string {
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
+
+// truncateRunes обрезает текст по символам, а не байтам, чтобы не разрывать кириллицу.
+func truncateRunes(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n]) + "…"
+}
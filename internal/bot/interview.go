@@ -0,0 +1,197 @@
+package bot
+
+import (
+	"BoardAI/internal/models"
+	"BoardAI/internal/state"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// interviewTimeout ограничивает подготовку вопросов и брифа.
+const interviewTimeout = 3 * time.Minute
+
+// submitIdea starts the analysis of an idea. A short idea without documents is first clarified
+// with a few questions to its author, otherwise the experts would have to invent the details.
+func (h *Handler) submitIdea(ctx context.Context, chatID, userID int64, idea string) {
+	// Фото или стикер приходят без текста: из пустой идеи не выйдет ни интервью, ни анализа.
+	idea = strings.TrimSpace(idea)
+	if idea == "" {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Опишите идею текстом, надиктуйте ее голосовым или приложите документ."))
+		return
+	}
+	if h.interviewBelow > 0 && len([]rune(idea)) < h.interviewBelow {
+		s, err := h.sessions.Get(ctx, userID)
+		if err != nil {
+			log.Printf("get session error: %v", err)
+		}
+		if s == nil || len(s.PendingAttachments) == 0 {
+			h.startInterview(ctx, chatID, userID, idea)
+			return
+		}
+	}
+	h.processIdea(ctx, chatID, userID, idea, "")
+}
+
+// startInterview asks the interviewer for clarifying questions in the background and sends the first one.
+// If no questions come back, the idea goes to the board as is.
+func (h *Handler) startInterview(ctx context.Context, chatID, userID int64, idea string) {
+	if _, ok := h.checkQuota(ctx, chatID, userID); !ok {
+		h.setState(ctx, userID, stateIdle)
+		return
+	}
+	if _, busy := h.interviewing.LoadOrStore(userID, struct{}{}); busy {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "⏳ Подождите немного, я еще думаю над предыдущим шагом."))
+		return
+	}
+
+	h.updateSession(ctx, userID, func(s *state.Session) {
+		s.State = stateInterview
+		s.PendingIdea = idea
+		s.Interview = nil
+		s.PendingTranscript = ""
+	})
+
+	h.bot.Request(tgbotapi.NewChatAction(chatID, tgbotapi.ChatTyping))
+	sent, _ := h.bot.Send(tgbotapi.NewMessage(chatID, "🔎 Идея описана коротко — задам несколько уточняющих вопросов, чтобы экспертам не пришлось додумывать..."))
+
+	go func() {
+		defer h.interviewing.Delete(userID)
+
+		ictx, cancel := context.WithTimeout(ctx, interviewTimeout)
+		defer cancel()
+
+		questions, err := h.orchestrator.Interview(ictx, idea, h.recordUsage(ctx, 0, userID))
+		if err != nil || len(questions) == 0 {
+			log.Printf("interview questions error: user=%d: %v", userID, err)
+			if h.userState(ctx, userID) == stateInterview {
+				h.bot.Send(tgbotapi.NewEditMessageText(chatID, sent.MessageID, "Не получилось подготовить вопросы — запускаю анализ по идее как есть."))
+				h.processIdea(ctx, chatID, userID, idea, "")
+			}
+			return
+		}
+
+		answers := make([]models.ClarifyingAnswer, len(questions))
+		for i, q := range questions {
+			answers[i] = models.ClarifyingAnswer{Question: q}
+		}
+		// Пока модель думала, пользователь мог отменить интервью или начать другой анализ.
+		var active bool
+		h.updateSession(ctx, userID, func(s *state.Session) {
+			if s.State == stateInterview && s.PendingIdea == idea {
+				s.Interview = answers
+				active = true
+			}
+		})
+		h.bot.Request(tgbotapi.NewDeleteMessage(chatID, sent.MessageID))
+		if active {
+			h.sendInterviewQuestion(chatID, answers, 0)
+		}
+	}()
+}
+
+// answerInterview records the author's answer to the current question; an empty answer skips it.
+// After the last question the brief is composed and the analysis starts.
+func (h *Handler) answerInterview(ctx context.Context, chatID, userID int64, answer string) {
+	if _, busy := h.interviewing.Load(userID); busy {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "⏳ Подождите немного, я еще думаю над предыдущим шагом."))
+		return
+	}
+
+	var answers []models.ClarifyingAnswer
+	next := -1
+	h.updateSession(ctx, userID, func(s *state.Session) {
+		i := nextQuestion(s.Interview)
+		if s.State != stateInterview || i < 0 {
+			return
+		}
+		s.Interview[i].Answer = answer
+		s.Interview[i].Skipped = answer == ""
+		answers = s.Interview
+		next = nextQuestion(s.Interview)
+	})
+	if answers == nil {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Вопросов больше нет. Нажмите «🆕 Новый анализ», чтобы начать заново."))
+		return
+	}
+	if next >= 0 {
+		h.sendInterviewQuestion(chatID, answers, next)
+		return
+	}
+	h.finishInterview(ctx, chatID, userID)
+}
+
+// finishInterview composes the brief from the answers given so far and queues the analysis with it.
+// Without a single answer there is nothing to add, and the idea goes to the board as is.
+func (h *Handler) finishInterview(ctx context.Context, chatID, userID int64) {
+	s, err := h.sessions.Get(ctx, userID)
+	if err != nil {
+		log.Printf("get session error: %v", err)
+		return
+	}
+	if s.State != stateInterview || s.PendingIdea == "" {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Уточнение уже завершено."))
+		return
+	}
+	idea, answers := s.PendingIdea, s.Interview
+
+	answered := false
+	for _, a := range answers {
+		answered = answered || a.Answer != ""
+	}
+	if !answered {
+		h.processIdea(ctx, chatID, userID, idea, "")
+		return
+	}
+
+	if _, busy := h.interviewing.LoadOrStore(userID, struct{}{}); busy {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "⏳ Подождите немного, я еще думаю над предыдущим шагом."))
+		return
+	}
+	h.bot.Request(tgbotapi.NewChatAction(chatID, tgbotapi.ChatTyping))
+	sent, _ := h.bot.Send(tgbotapi.NewMessage(chatID, "📝 Составляю бриф для совета..."))
+
+	go func() {
+		defer h.interviewing.Delete(userID)
+
+		ictx, cancel := context.WithTimeout(ctx, interviewTimeout)
+		defer cancel()
+
+		brief, err := h.orchestrator.ComposeBrief(ictx, idea, answers, h.recordUsage(ctx, 0, userID))
+		if err != nil {
+			log.Printf("compose brief error: user=%d: %v", userID, err)
+			return
+		}
+		h.bot.Request(tgbotapi.NewDeleteMessage(chatID, sent.MessageID))
+		if h.userState(ctx, userID) != stateInterview {
+			return
+		}
+		h.sendLongText(chatID, "📝 Бриф для совета:\n\n"+brief, nil)
+		h.processIdea(ctx, chatID, userID, idea, brief)
+	}()
+}
+
+// sendInterviewQuestion sends question i of the interview with the skip and run buttons.
+func (h *Handler) sendInterviewQuestion(chatID int64, answers []models.ClarifyingAnswer, i int) {
+	text := fmt.Sprintf("❓ Вопрос %d из %d\n\n%s", i+1, len(answers), answers[i].Question)
+	if i == 0 {
+		text += "\n\nОтветьте сообщением. Вопрос можно пропустить, а анализ — запустить в любой момент."
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = buildInterviewKeyboard()
+	h.bot.Send(msg)
+}
+
+// nextQuestion returns the index of the first unanswered question, or -1.
+func nextQuestion(answers []models.ClarifyingAnswer) int {
+	for i, a := range answers {
+		if a.Pending() {
+			return i
+		}
+	}
+	return -1
+}
@@ -1,22 +1,331 @@
 package bot
 
-import tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+import (
+	"BoardAI/internal/board"
+	"BoardAI/internal/export"
+	"BoardAI/internal/models"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
 
 const (
-	callbackNewAnalysis  = "new_analysis"
+	callbackNewAnalysis = "new_analysis"
+	// callbackSaveAnalysis осталась на клавиатурах старых сообщений: теперь она добавляет последний анализ в избранное.
 	callbackSaveAnalysis = "save_analysis"
 	callbackListHistory  = "list_history"
+	callbackShowDebate   = "show_debate"
+
+	// Callback-и с аргументом имеют вид "<префикс>:<число>".
+	callbackHistoryPage   = "hist_page"
+	callbackHistoryOpen   = "hist_open"
+	callbackHistoryDelete = "hist_del"
+	callbackDeleteConfirm = "hist_del_ok"
+	// Экспорт: "export:<id>" открывает выбор формата, "export_<формат>:<id>" присылает файл.
+	// id 0 означает последний анализ пользователя, еще не сохраненный в истории.
+	callbackExport   = "export"
+	callbackExportAs = "export_"
+	// "cancel_job:<id задачи>" — кнопка «Отменить» под сообщением об идущем анализе.
+	callbackCancelJob = "cancel_job"
+	// Избранное: "pin:<id>" и "unpin:<id>".
+	callbackPin   = "pin"
+	callbackUnpin = "unpin"
+	// Вопросы эксперту: "ask:<id анализа>" открывает выбор эксперта, "ask_<роль>:<id анализа>"
+	// начинает разговор, "ask_done" его завершает.
+	callbackAskExpert   = "ask"
+	callbackAskExpertAs = "ask_"
+	callbackAskDone     = "ask_done"
+	// Подтверждение расшифровки голосового сообщения.
+	callbackVoiceConfirm = "voice_ok"
+	callbackVoiceCancel  = "voice_cancel"
+	// Уточняющее интервью: пропустить текущий вопрос или сразу запустить анализ.
+	callbackInterviewSkip = "brief_skip"
+	callbackInterviewRun  = "brief_run"
+	// Сравнение: "compare" открывает выбор, "cmp_pick:<id>" отмечает анализ, "cmp_run" запускает сравнение.
+	callbackCompare     = "compare"
+	callbackComparePick = "cmp_pick"
+	callbackCompareRun  = "cmp_run"
+	// Повторный прогон: "rerun:<id>" открывает выбор эксперта, "rerun_<роль>:<id>" — выбор модели,
+	// "rrun<n>_<роль>:<id>" перезапускает эксперта на n-й модели из списка (0 — его собственная).
+	// "reverdict:<id>" пересчитывает вердикт, "revisions:<id>" показывает прежние версии.
+	callbackRerun     = "rerun"
+	callbackRerunAs   = "rerun_"
+	callbackRerunWith = "rrun"
+	callbackReverdict = "reverdict"
+	callbackRevisions = "revisions"
 )
 
+func callbackWithArg(prefix string, arg int64) string {
+	return fmt.Sprintf("%s:%d", prefix, arg)
+}
+
+// parseCallback splits callback data into its prefix and optional numeric argument.
+func parseCallback(data string) (string, int64, bool) {
+	prefix, rawArg, found := strings.Cut(data, ":")
+	if !found {
+		return prefix, 0, false
+	}
+	arg, err := strconv.ParseInt(rawArg, 10, 64)
+	if err != nil {
+		return prefix, 0, false
+	}
+	return prefix, arg, true
+}
+
 func buildMainKeyboard() *tgbotapi.InlineKeyboardMarkup {
 	kb := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData("🆕 Новый анализ", callbackNewAnalysis),
 		),
 		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("💾 Сохранить анализ", callbackSaveAnalysis),
 			tgbotapi.NewInlineKeyboardButtonData("📜 Мои анализы", callbackListHistory),
 		),
 	)
 	return &kb
 }
+
+// buildAnalysisKeyboard is the main keyboard plus actions that only make sense for a finished analysis.
+// Without an id (the run was not recorded in the history) the actions refer to the user's last analysis.
+func buildAnalysisKeyboard(a *models.Analysis) *tgbotapi.InlineKeyboardMarkup {
+	kb := buildMainKeyboard()
+	row := tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("📄 Экспорт", callbackWithArg(callbackExport, a.ID)),
+	)
+	if len(a.Rounds) > 0 {
+		debate := callbackShowDebate
+		if a.ID != 0 {
+			debate = callbackWithArg(callbackShowDebate, a.ID)
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("🗣 Ход дебатов", debate))
+	}
+	if a.ID != 0 {
+		row = append(row, buildPinButton(a.ID, a.Pinned))
+	}
+	kb.InlineKeyboard = append(kb.InlineKeyboard, row)
+	if a.ID != 0 && len(a.Reports) > 0 {
+		kb.InlineKeyboard = append(kb.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(buildAskExpertButton(a.ID)), buildRerunRow(a.ID))
+	}
+	return kb
+}
+
+func buildAskExpertButton(id int64) tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardButtonData("💬 Спросить эксперта", callbackWithArg(callbackAskExpert, id))
+}
+
+// buildRerunRow offers to rerun one expert or the verdict of a finished analysis.
+func buildRerunRow(id int64) []tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔁 Перезапустить эксперта", callbackWithArg(callbackRerun, id)),
+		tgbotapi.NewInlineKeyboardButtonData("🏁 Пересчитать вердикт", callbackWithArg(callbackReverdict, id)),
+	)
+}
+
+// buildExpertsKeyboard lists the experts whose reports the analysis has, two per row;
+// each button sends prefix followed by the role id.
+func buildExpertsKeyboard(b *board.Board, a *models.Analysis, prefix string) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, r := range b.Experts() {
+		if _, ok := a.Report(r.ID); !ok {
+			continue
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(r.Title(), callbackWithArg(prefix+r.ID, a.ID)))
+		if len(row) == 2 {
+			rows = append(rows, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		rows = append(rows, row)
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// buildRerunModelsKeyboard lists the models the expert can be rerun with, its own one first.
+func buildRerunModelsKeyboard(id int64, role string, choices []string) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for i, m := range choices {
+		label := m
+		if i == 0 {
+			label = "Та же модель · " + m
+		}
+		data := callbackWithArg(fmt.Sprintf("%s%d_%s", callbackRerunWith, i, role), id)
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(tgbotapi.NewInlineKeyboardButtonData(label, data)))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// buildRerunResultKeyboard is attached to the new version of an expert's report.
+func buildRerunResultKeyboard(id int64, role string) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🏁 Пересчитать вердикт", callbackWithArg(callbackReverdict, id)),
+			tgbotapi.NewInlineKeyboardButtonData("🔁 Еще раз", callbackWithArg(callbackRerunAs+role, id)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🗂 Прежние версии", callbackWithArg(callbackRevisions, id)),
+			tgbotapi.NewInlineKeyboardButtonData("📜 Открыть анализ", callbackWithArg(callbackHistoryOpen, id)),
+		),
+	)
+}
+
+// buildFollowUpKeyboard is attached to the expert's answers.
+func buildFollowUpKeyboard(id int64) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("👥 Другой эксперт", callbackWithArg(callbackAskExpert, id)),
+			tgbotapi.NewInlineKeyboardButtonData("✅ Закончить", callbackAskDone),
+		),
+	)
+}
+
+// buildVoiceConfirmKeyboard is attached to the transcript of a voice message.
+func buildVoiceConfirmKeyboard() tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🚀 Запустить анализ", callbackVoiceConfirm),
+			tgbotapi.NewInlineKeyboardButtonData("✖️ Отмена", callbackVoiceCancel),
+		),
+	)
+}
+
+// buildInterviewKeyboard is attached to every clarifying question.
+func buildInterviewKeyboard() tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⏭ Пропустить", callbackInterviewSkip),
+			tgbotapi.NewInlineKeyboardButtonData("🚀 Запустить анализ", callbackInterviewRun),
+		),
+	)
+}
+
+// emptyInlineKeyboard removes the buttons from a message once they have been used.
+func emptyInlineKeyboard() tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.InlineKeyboardMarkup{InlineKeyboard: [][]tgbotapi.InlineKeyboardButton{}}
+}
+
+func buildCancelKeyboard(jobID int64) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⛔ Отменить", callbackWithArg(callbackCancelJob, jobID)),
+		),
+	)
+}
+
+func buildPinButton(id int64, pinned bool) tgbotapi.InlineKeyboardButton {
+	if pinned {
+		return tgbotapi.NewInlineKeyboardButtonData("★ Убрать из избранного", callbackWithArg(callbackUnpin, id))
+	}
+	return tgbotapi.NewInlineKeyboardButtonData("⭐ В избранное", callbackWithArg(callbackPin, id))
+}
+
+// replacePinButton flips the pin button of analysis id in an existing keyboard; it reports
+// whether the keyboard had such a button.
+func replacePinButton(kb *tgbotapi.InlineKeyboardMarkup, id int64, pinned bool) bool {
+	if kb == nil {
+		return false
+	}
+	for i, row := range kb.InlineKeyboard {
+		for j, b := range row {
+			if b.CallbackData == nil {
+				continue
+			}
+			if data := *b.CallbackData; data == callbackWithArg(callbackPin, id) || data == callbackWithArg(callbackUnpin, id) {
+				kb.InlineKeyboard[i][j] = buildPinButton(id, pinned)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// buildStoredAnalysisKeyboard is attached to an analysis opened from the history.
+func buildStoredAnalysisKeyboard(a *models.Analysis) *tgbotapi.InlineKeyboardMarkup {
+	var top []tgbotapi.InlineKeyboardButton
+	// Незавершенный анализ нечего выгружать, но его можно оставить в избранном или удалить.
+	if a.Status == models.AnalysisCompleted {
+		top = append(top, tgbotapi.NewInlineKeyboardButtonData("📄 Экспорт", callbackWithArg(callbackExport, a.ID)))
+	}
+	if len(a.Rounds) > 0 {
+		top = append(top, tgbotapi.NewInlineKeyboardButtonData("🗣 Ход дебатов", callbackWithArg(callbackShowDebate, a.ID)))
+	}
+	top = append(top, buildPinButton(a.ID, a.Pinned))
+	rows := [][]tgbotapi.InlineKeyboardButton{top}
+	if a.Status == models.AnalysisCompleted && len(a.Reports) > 0 {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(buildAskExpertButton(a.ID)), buildRerunRow(a.ID))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🗑 Удалить", callbackWithArg(callbackHistoryDelete, a.ID)),
+		tgbotapi.NewInlineKeyboardButtonData("📜 К списку", callbackWithArg(callbackHistoryPage, 0)),
+	))
+	kb := tgbotapi.NewInlineKeyboardMarkup(rows...)
+	return &kb
+}
+
+func buildHistoryKeyboard(analyses []*models.Analysis, page int, hasNext bool) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, a := range analyses {
+		label := fmt.Sprintf("%s #%d %s", historyIcon(a), a.ID, truncateRunes(a.IdeaText, 30))
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label, callbackWithArg(callbackHistoryOpen, a.ID)),
+		))
+	}
+
+	var nav []tgbotapi.InlineKeyboardButton
+	if page > 0 {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("←", callbackWithArg(callbackHistoryPage, int64(page-1))))
+	}
+	if hasNext {
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("→", callbackWithArg(callbackHistoryPage, int64(page+1))))
+	}
+	if len(nav) > 0 {
+		rows = append(rows, nav)
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("⚖️ Сравнить анализы", callbackCompare),
+	))
+
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+// buildCompareKeyboard lists analyses with checkboxes; the run button appears once enough are selected.
+func buildCompareKeyboard(analyses []*models.Analysis, selected []int64) tgbotapi.InlineKeyboardMarkup {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	for _, a := range analyses {
+		mark := "☐"
+		if slices.Contains(selected, a.ID) {
+			mark = "☑️"
+		}
+		label := fmt.Sprintf("%s #%d %s", mark, a.ID, truncateRunes(a.IdeaText, 30))
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label, callbackWithArg(callbackComparePick, a.ID)),
+		))
+	}
+	if len(selected) >= compareMin {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("⚖️ Сравнить (%d)", len(selected)), callbackCompareRun),
+		))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(rows...)
+}
+
+func buildDeleteConfirmKeyboard(id int64) tgbotapi.InlineKeyboardMarkup {
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Да, удалить", callbackWithArg(callbackDeleteConfirm, id)),
+			tgbotapi.NewInlineKeyboardButtonData("Отмена", callbackWithArg(callbackHistoryPage, 0)),
+		),
+	)
+}
+
+// buildExportKeyboard offers the export formats for analysis id (0 — the last one).
+func buildExportKeyboard(id int64) tgbotapi.InlineKeyboardMarkup {
+	var row []tgbotapi.InlineKeyboardButton
+	for _, f := range export.Formats {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(f.Title(), callbackWithArg(callbackExportAs+string(f), id)))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(row)
+}
@@ -1,42 +1,63 @@
 package bot
 
 import (
+	"BoardAI/internal/board"
 	"BoardAI/internal/models"
 	"fmt"
 	"strings"
 )
 
-func escapeMarkdownV2(text string) string {
-	replacer := strings.NewReplacer(
-		`_`, `\_`,
-		`*`, `\*`,
-		`[`, `\[`,
-		`]`, `\]`,
-		`(`, `\(`,
-		`)`, `\)`,
-		`~`, `\~`,
-		"`", "\\`",
-		`>`, `\>`,
-		`#`, `\#`,
-		`+`, `\+`,
-		`-`, `\-`,
-		`=`, `\=`,
-		`|`, `\|`,
-		`{`, `\{`,
-		`}`, `\}`,
-		`.`, `\.`,
-		`!`, `\!`,
-	)
-	return replacer.Replace(text)
+func renderAnalysisMarkdown(a *models.Analysis, b *board.Board) string {
+	var sb strings.Builder
+	sb.WriteString("📊 РЕЗУЛЬТАТЫ АНАЛИЗА\n\n")
+	fmt.Fprintf(&sb, "💡 ИДЕЯ: %s\n\n", a.IdeaText)
+	if a.Brief != "" {
+		fmt.Fprintf(&sb, "📝 БРИФ:\n%s\n\n", a.Brief)
+	}
+	fmt.Fprintf(&sb, "%s:\n%s\n", strings.TrimSpace(b.Moderator().Emoji+" ВЕРДИКТ МОДЕРАТОРА"), a.Moderator)
+	for _, r := range a.Reports {
+		fmt.Fprintf(&sb, "\n%s:\n%s\n", strings.ToUpper(b.Title(r.Role)), r.Content)
+	}
+	return sb.String()
 }
 
-func renderAnalysisMarkdown(a *models.Analysis) string {
-	return fmt.Sprintf(
-		"📊 РЕЗУЛЬТАТЫ АНАЛИЗА\n\n"+
-			"💡 ИДЕЯ: %s\n\n"+
-			"👨‍💼 ВЕРДИКТ МОДЕРАТОРА:\n%s\n\n"+
-			"📈 СТРАТЕГИЯ:\n%s\n\n"+
-			"💰 ФИНАНСЫ:\n%s\n",
-		a.IdeaText, a.Moderator, a.Strategist, a.Financier,
-	)
+func renderDebate(a *models.Analysis, b *board.Board) string {
+	var sb strings.Builder
+	sb.WriteString("🗣 ХОД ДЕБАТОВ СОВЕТА\n\n")
+	fmt.Fprintf(&sb, "💡 ИДЕЯ: %s\n", a.IdeaText)
+
+	for _, round := range a.Rounds {
+		if round.Round == 1 {
+			sb.WriteString("\n━━━ Раунд 1: исходные отчеты ━━━\n")
+		} else {
+			fmt.Fprintf(&sb, "\n━━━ Раунд %d: опровержения ━━━\n", round.Round)
+		}
+		for _, e := range round.Entries {
+			fmt.Fprintf(&sb, "\n%s:\n%s\n", b.Title(e.Role), e.Content)
+		}
+	}
+
+	return sb.String()
+}
+
+// splitMessage режет текст на части не длиннее limit символов, не разрывая UTF-8 руны
+// и по возможности по переводу строки.
+func splitMessage(text string, limit int) []string {
+	runes := []rune(text)
+	var parts []string
+	for len(runes) > limit {
+		cut := limit
+		for i := limit; i > limit/2; i-- {
+			if runes[i-1] == '\n' {
+				cut = i
+				break
+			}
+		}
+		parts = append(parts, string(runes[:cut]))
+		runes = runes[cut:]
+	}
+	if len(runes) > 0 {
+		parts = append(parts, string(runes))
+	}
+	return parts
 }
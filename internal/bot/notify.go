@@ -0,0 +1,129 @@
+package bot
+
+import (
+	"BoardAI/internal/models"
+	"BoardAI/internal/orchestrator"
+	"BoardAI/internal/state"
+	"context"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// JobStarted implements queue.Notifier: the waiting message starts showing live agent output.
+func (h *Handler) JobStarted(job *models.Job) orchestrator.ProgressFunc {
+	// Воркер может взять задачу раньше, чем processIdea запомнит ее id в сессии.
+	h.updateSession(context.Background(), job.UserID, func(s *state.Session) {
+		if s.State == stateProcessing && s.PendingJobID == 0 {
+			s.PendingJobID = job.ID
+		}
+	})
+	if job.MessageID == 0 {
+		return nil
+	}
+
+	live := newLiveMessage(h.bot, h.board, job.ChatID, job.MessageID, buildCancelKeyboard(job.ID))
+	h.live.Store(job.ID, live)
+	return live.Update
+}
+
+// JobCompleted implements queue.Notifier: the result replaces the waiting message.
+func (h *Handler) JobCompleted(job *models.Job, analysis *models.Analysis) {
+	h.stopLive(job.ID)
+
+	// Результат уже записан в задачу; в сессии достаточно запомнить ее id.
+	h.updateSession(context.Background(), job.UserID, func(s *state.Session) {
+		if waitsFor(s, job.ID) {
+			s.State = stateLastAnalysis
+		}
+		if s.PendingJobID == job.ID {
+			s.PendingJobID = 0
+		}
+		s.LastJobID = job.ID
+	})
+
+	fullText := renderAnalysisMarkdown(analysis, h.board)
+
+	if len(fullText) < 4000 && job.MessageID != 0 {
+		edit := tgbotapi.NewEditMessageText(job.ChatID, job.MessageID, fullText)
+		edit.ReplyMarkup = buildAnalysisKeyboard(analysis)
+		h.bot.Send(edit)
+		return
+	}
+
+	if job.MessageID != 0 {
+		h.bot.Send(tgbotapi.NewDeleteMessage(job.ChatID, job.MessageID))
+	}
+	h.sendLongText(job.ChatID, fullText, buildAnalysisKeyboard(analysis))
+}
+
+// JobFailed implements queue.Notifier.
+func (h *Handler) JobFailed(job *models.Job, err error, willRetry bool) {
+	h.stopLive(job.ID)
+
+	text := "⚠️ Ошибка анализа. Попробуйте позже."
+	if willRetry {
+		text = "⚠️ Ошибка анализа, пробую еще раз..."
+	} else {
+		h.updateSession(context.Background(), job.UserID, func(s *state.Session) {
+			leaveJob(s, job.ID)
+		})
+	}
+
+	if job.MessageID != 0 {
+		edit := tgbotapi.NewEditMessageText(job.ChatID, job.MessageID, text)
+		if willRetry {
+			// Задача вернулась в очередь, и ее по-прежнему можно отменить.
+			kb := buildCancelKeyboard(job.ID)
+			edit.ReplyMarkup = &kb
+		}
+		h.bot.Send(edit)
+		return
+	}
+	h.bot.Send(tgbotapi.NewMessage(job.ChatID, text))
+}
+
+// JobCancelled implements queue.Notifier.
+func (h *Handler) JobCancelled(job *models.Job) {
+	h.stopLive(job.ID)
+	h.finishCancelled(context.Background(), job)
+}
+
+// finishCancelled resets the user's session and replaces the waiting message.
+func (h *Handler) finishCancelled(ctx context.Context, job *models.Job) {
+	h.updateSession(ctx, job.UserID, func(s *state.Session) {
+		leaveJob(s, job.ID)
+	})
+
+	text := "⛔ Анализ отменен."
+	if job.MessageID != 0 {
+		h.bot.Send(tgbotapi.NewEditMessageTextAndMarkup(job.ChatID, job.MessageID, text, *buildMainKeyboard()))
+		return
+	}
+	resp := tgbotapi.NewMessage(job.ChatID, text)
+	resp.ReplyMarkup = buildMainKeyboard()
+	h.bot.Send(resp)
+}
+
+// waitsFor reports whether the session still shows the progress of the job: while it ran
+// the user may have started a follow-up, an interview or a new idea, and those must not be
+// interrupted when the job ends.
+func waitsFor(s *state.Session, jobID int64) bool {
+	return s.State == stateProcessing && s.PendingJobID == jobID
+}
+
+// leaveJob forgets a job that ended without a result, returning the session to idle only if it
+// was still waiting on that job.
+func leaveJob(s *state.Session, jobID int64) {
+	if waitsFor(s, jobID) {
+		s.State = stateIdle
+	}
+	if s.PendingJobID == jobID {
+		s.PendingJobID = 0
+	}
+}
+
+func (h *Handler) stopLive(jobID int64) {
+	if val, ok := h.live.LoadAndDelete(jobID); ok {
+		val.(*liveMessage).Stop()
+	}
+}
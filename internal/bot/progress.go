@@ -0,0 +1,128 @@
+package bot
+
+import (
+	"BoardAI/internal/agents"
+	"BoardAI/internal/board"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Telegram ограничивает частоту правок одного сообщения, поэтому обновляем не чаще раза в несколько секунд.
+const (
+	progressEditInterval = 3 * time.Second
+	progressMaxChars     = 3500
+)
+
+// liveMessage progressively edits the waiting message with the text streamed by agents.
+type liveMessage struct {
+	bot       *tgbotapi.BotAPI
+	board     *board.Board
+	chatID    int64
+	messageID int
+	// markup сохраняется при каждой правке: без него Telegram убрал бы кнопку «Отменить».
+	markup tgbotapi.InlineKeyboardMarkup
+
+	mu    sync.Mutex
+	order []agents.Role
+	texts map[agents.Role]string
+	dirty bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newLiveMessage(bot *tgbotapi.BotAPI, b *board.Board, chatID int64, messageID int, markup tgbotapi.InlineKeyboardMarkup) *liveMessage {
+	lm := &liveMessage{
+		bot:       bot,
+		board:     b,
+		chatID:    chatID,
+		messageID: messageID,
+		markup:    markup,
+		texts:     make(map[agents.Role]string),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go lm.loop()
+	return lm
+}
+
+// Update remembers the latest text of the agent; it is cheap and safe to call on every delta,
+// including from several agents running in parallel.
+func (lm *liveMessage) Update(role agents.Role, text string) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	if _, ok := lm.texts[role]; !ok {
+		lm.order = append(lm.order, role)
+	}
+	lm.texts[role] = text
+	lm.dirty = true
+}
+
+// Stop finishes the edit loop. No edits are sent after Stop returns.
+func (lm *liveMessage) Stop() {
+	close(lm.stop)
+	<-lm.done
+}
+
+func (lm *liveMessage) loop() {
+	defer close(lm.done)
+
+	ticker := time.NewTicker(progressEditInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-lm.stop:
+			return
+		case <-ticker.C:
+			lm.flush()
+		}
+	}
+}
+
+func (lm *liveMessage) flush() {
+	lm.mu.Lock()
+	if !lm.dirty {
+		lm.mu.Unlock()
+		return
+	}
+	text := renderProgress(lm.board, lm.order, lm.texts)
+	lm.dirty = false
+	lm.mu.Unlock()
+
+	edit := tgbotapi.NewEditMessageTextAndMarkup(lm.chatID, lm.messageID, text, lm.markup)
+	if _, err := lm.bot.Request(edit); err != nil {
+		log.Printf("progress edit error: %v", err)
+	}
+}
+
+// renderProgress shows the tail of every agent's answer; the character budget is split between them
+// so the message stays under the Telegram limit.
+func renderProgress(b *board.Board, order []agents.Role, texts map[agents.Role]string) string {
+	var sb strings.Builder
+	sb.WriteString("⏳ Анализ идет...")
+
+	budget := progressMaxChars
+	if len(order) > 0 {
+		budget = progressMaxChars / len(order)
+	}
+
+	for _, role := range order {
+		title := b.Title(string(role))
+
+		runes := []rune(texts[role])
+		text := string(runes)
+		if len(runes) > budget {
+			text = "…" + string(runes[len(runes)-budget:])
+		}
+
+		fmt.Fprintf(&sb, "\n\n%s пишет:\n%s", title, text)
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,62 @@
+package bot
+
+import (
+	"BoardAI/internal/quota"
+	"context"
+	"fmt"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// checkQuota explains to the user why a new analysis cannot start; it returns the quota and whether
+// the analysis may go ahead. An error of the check itself does not block the user.
+func (h *Handler) checkQuota(ctx context.Context, chatID, userID int64) (quota.Status, bool) {
+	st, err := h.quota.Check(ctx, userID)
+	if err != nil {
+		log.Printf("quota check error: %v", err)
+		return st, true
+	}
+	if st.Allowed() {
+		return st, true
+	}
+
+	resp := tgbotapi.NewMessage(chatID, renderQuotaRefusal(st))
+	resp.ReplyMarkup = buildMainKeyboard()
+	h.bot.Send(resp)
+	return st, false
+}
+
+func renderQuotaRefusal(st quota.Status) string {
+	switch st.Reason {
+	case quota.ReasonConcurrent:
+		if st.Limits.MaxConcurrent == 1 {
+			return "Анализ уже идет, пожалуйста, подождите. Остановить его можно командой /cancel."
+		}
+		return fmt.Sprintf("У вас уже идет %d анализов — это максимум. Дождитесь результата или остановите анализ командой /cancel.",
+			st.Active)
+	case quota.ReasonDaily:
+		return fmt.Sprintf("🚫 Дневной лимит исчерпан: %d из %d анализов. Новые анализы станут доступны %s.",
+			st.UsedToday, st.Limits.PerDay, formatResetAt(st))
+	case quota.ReasonQueueFull:
+		return fmt.Sprintf("🚦 Очередь переполнена: ждут %d анализов. Попробуйте через несколько минут.", st.QueueDepth)
+	}
+	return ""
+}
+
+// renderQuotaLeft describes the daily quota after `started` more analyses were queued; empty without a limit.
+func renderQuotaLeft(st quota.Status, started int) string {
+	left := st.RemainingToday()
+	if left < 0 {
+		return ""
+	}
+	left -= started
+	if left < 0 {
+		left = 0
+	}
+	return fmt.Sprintf("Осталось анализов на сегодня: %d из %d (лимит обновится %s).", left, st.Limits.PerDay, formatResetAt(st))
+}
+
+func formatResetAt(st quota.Status) string {
+	return st.ResetAt.Format("02.01 в 15:04 MST")
+}
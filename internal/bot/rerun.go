@@ -0,0 +1,208 @@
+package bot
+
+import (
+	"BoardAI/internal/models"
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// rerunTimeout ограничивает повторный прогон одного эксперта или вердикта.
+	rerunTimeout = 10 * time.Minute
+	// revisionsShown — сколько последних прежних версий показывается за раз.
+	revisionsShown = 5
+)
+
+// chooseRerunExpert asks which expert of the analysis should prepare the report again.
+func (h *Handler) chooseRerunExpert(ctx context.Context, chatID, userID, id int64) {
+	a, ok := h.loadRevisableAnalysis(ctx, chatID, userID, id)
+	if !ok {
+		return
+	}
+	kb := buildExpertsKeyboard(h.board, a, callbackRerunAs)
+	kb.InlineKeyboard = append(kb.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🗂 Прежние версии", callbackWithArg(callbackRevisions, a.ID)),
+	))
+	resp := tgbotapi.NewMessage(chatID, fmt.Sprintf("Чей отчет по анализу #%d подготовить заново? Текущая версия сохранится в истории.", a.ID))
+	resp.ReplyMarkup = kb
+	h.bot.Send(resp)
+}
+
+// chooseRerunModel asks which model the expert should use; with a single option it reruns at once.
+func (h *Handler) chooseRerunModel(ctx context.Context, chatID, userID, id int64, role string) {
+	choices := h.orchestrator.RerunModels(role)
+	if len(choices) == 0 {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Такого эксперта больше нет в совете."))
+		return
+	}
+	if len(choices) == 1 {
+		h.rerunExpert(ctx, chatID, userID, id, role, 0)
+		return
+	}
+	resp := tgbotapi.NewMessage(chatID, fmt.Sprintf("На какой модели %s подготовит отчет?", h.board.Title(role)))
+	resp.ReplyMarkup = buildRerunModelsKeyboard(id, role, choices)
+	h.bot.Send(resp)
+}
+
+// rerunExpert prepares the report of role again on model n of RerunModels and stores the old one
+// as a revision. The expert works in the background so other updates are not held up.
+func (h *Handler) rerunExpert(ctx context.Context, chatID, userID, id int64, role string, n int) {
+	choices := h.orchestrator.RerunModels(role)
+	if n < 0 || n >= len(choices) {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Этой модели больше нет в совете. Выберите эксперта еще раз."))
+		return
+	}
+	model := choices[n]
+
+	if _, busy := h.rerunning.LoadOrStore(userID, struct{}{}); busy {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "⏳ Предыдущий перезапуск еще идет, подождите."))
+		return
+	}
+	// Анализ читаем уже под флагом: иначе можно затереть результат только что завершившегося перезапуска.
+	a, ok := h.loadRevisableAnalysis(ctx, chatID, userID, id)
+	if !ok {
+		h.rerunning.Delete(userID)
+		return
+	}
+
+	h.bot.Request(tgbotapi.NewChatAction(chatID, tgbotapi.ChatTyping))
+	sent, _ := h.bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("🔁 %s заново готовит отчет (%s)...", h.board.Title(role), model)))
+
+	go func() {
+		defer h.rerunning.Delete(userID)
+
+		rctx, cancel := context.WithTimeout(ctx, rerunTimeout)
+		defer cancel()
+
+		rev, err := h.orchestrator.RerunExpert(rctx, a, role, model, h.recordUsage(ctx, a.ID, userID))
+		if err == nil {
+			err = h.repo.Revise(ctx, a, rev)
+		}
+		if err != nil {
+			log.Printf("rerun expert error: analysis=%d role=%s: %v", a.ID, role, err)
+			h.bot.Send(tgbotapi.NewEditMessageText(chatID, sent.MessageID, "⚠️ Не удалось подготовить отчет заново. Прежняя версия осталась в анализе."))
+			return
+		}
+
+		report, _ := a.Report(role)
+		// Собственная модель роли могла не ответить, и отчет написала запасная.
+		if used := a.AgentRuns[len(a.AgentRuns)-1].Model; used != "" {
+			model = used
+		}
+		text := fmt.Sprintf("🔁 %s — новая версия отчета по анализу #%d (%s):\n\n%s\n\n"+
+			"Прежняя версия сохранена. Вердикт модератора пока опирается на старый отчет — его можно пересчитать.",
+			h.board.Title(role), a.ID, model, report)
+		h.bot.Request(tgbotapi.NewDeleteMessage(chatID, sent.MessageID))
+		h.sendLongText(chatID, text, buildRerunResultKeyboard(a.ID, role))
+	}()
+}
+
+// regenerateVerdict asks the moderator for a new verdict on the current reports of the analysis
+// and stores the old verdict as a revision.
+func (h *Handler) regenerateVerdict(ctx context.Context, chatID, userID, id int64) {
+	if _, busy := h.rerunning.LoadOrStore(userID, struct{}{}); busy {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "⏳ Предыдущий перезапуск еще идет, подождите."))
+		return
+	}
+	a, ok := h.loadRevisableAnalysis(ctx, chatID, userID, id)
+	if !ok {
+		h.rerunning.Delete(userID)
+		return
+	}
+
+	h.bot.Request(tgbotapi.NewChatAction(chatID, tgbotapi.ChatTyping))
+	sent, _ := h.bot.Send(tgbotapi.NewMessage(chatID, "🏁 Модератор заново выносит вердикт по текущим отчетам..."))
+
+	go func() {
+		defer h.rerunning.Delete(userID)
+
+		rctx, cancel := context.WithTimeout(ctx, rerunTimeout)
+		defer cancel()
+
+		rev, err := h.orchestrator.RegenerateVerdict(rctx, a, h.recordUsage(ctx, a.ID, userID))
+		if err == nil {
+			err = h.repo.Revise(ctx, a, rev)
+		}
+		if err != nil {
+			log.Printf("regenerate verdict error: analysis=%d: %v", a.ID, err)
+			h.bot.Send(tgbotapi.NewEditMessageText(chatID, sent.MessageID, "⚠️ Не удалось пересчитать вердикт. Прежний вердикт остался в анализе."))
+			return
+		}
+
+		h.bot.Request(tgbotapi.NewDeleteMessage(chatID, sent.MessageID))
+		h.sendLongText(chatID, fmt.Sprintf("🏁 Новый вердикт по анализу #%d:\n\n%s\n\nПрежний вердикт сохранен.", a.ID, a.Moderator),
+			buildStoredAnalysisKeyboard(a))
+	}()
+}
+
+// showRevisions sends the replaced versions of the analysis's reports and verdict, newest last.
+func (h *Handler) showRevisions(ctx context.Context, chatID, userID, id int64) {
+	a, ok := h.loadOwnAnalysis(ctx, chatID, userID, id)
+	if !ok {
+		return
+	}
+	revs, err := h.repo.Revisions(ctx, a.ID)
+	if err != nil {
+		log.Printf("list revisions error: %v", err)
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Не удалось загрузить прежние версии."))
+		return
+	}
+	if len(revs) == 0 {
+		h.bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("У анализа #%d еще нет прежних версий: эксперты и модератор не перезапускались.", a.ID)))
+		return
+	}
+	h.sendLongText(chatID, h.renderRevisions(a.ID, revs), buildStoredAnalysisKeyboard(a))
+}
+
+func (h *Handler) renderRevisions(id int64, revs []*models.Revision) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🗂 ПРЕЖНИЕ ВЕРСИИ АНАЛИЗА #%d\n", id)
+	if len(revs) > revisionsShown {
+		fmt.Fprintf(&sb, "Показаны последние %d из %d.\n", revisionsShown, len(revs))
+		revs = revs[len(revs)-revisionsShown:]
+	}
+	for _, rev := range revs {
+		title := h.board.Title(rev.Role)
+		if rev.Kind == models.RevisionVerdict {
+			title = "🏁 Вердикт модератора"
+		}
+		model := rev.Model
+		if model == "" {
+			model = "модель неизвестна"
+		}
+		fmt.Fprintf(&sb, "\n▪️ %s · заменена %s · %s\n%s\n", title, rev.CreatedAt.Format("02.01.2006 15:04"), model, rev.Content)
+	}
+	return sb.String()
+}
+
+// loadRevisableAnalysis loads the user's analysis if its reports or verdict can be rerun.
+func (h *Handler) loadRevisableAnalysis(ctx context.Context, chatID, userID, id int64) (*models.Analysis, bool) {
+	a, ok := h.loadOwnAnalysis(ctx, chatID, userID, id)
+	if !ok {
+		return nil, false
+	}
+	if a.Status != models.AnalysisCompleted || len(a.Reports) == 0 {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Перезапускать экспертов можно только в завершенном анализе."))
+		return nil, false
+	}
+	return a, true
+}
+
+// parseRerunWith splits "<n>_<роль>", the tail of a callbackRerunWith prefix.
+func parseRerunWith(s string) (int, string, bool) {
+	raw, role, found := strings.Cut(s, "_")
+	if !found || role == "" {
+		return 0, "", false
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, "", false
+	}
+	return n, role, true
+}
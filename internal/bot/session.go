@@ -0,0 +1,53 @@
+package bot
+
+import (
+	"BoardAI/internal/models"
+	"BoardAI/internal/state"
+	"context"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// userState returns the user's dialog state; if the store is unavailable the user is treated as idle.
+func (h *Handler) userState(ctx context.Context, userID int64) string {
+	s, err := h.sessions.Get(ctx, userID)
+	if err != nil {
+		log.Printf("get session error: %v", err)
+		return stateIdle
+	}
+	return s.State
+}
+
+func (h *Handler) setState(ctx context.Context, userID int64, st string) {
+	h.updateSession(ctx, userID, func(s *state.Session) {
+		s.State = st
+	})
+}
+
+func (h *Handler) updateSession(ctx context.Context, userID int64, fn func(s *state.Session)) {
+	if err := h.sessions.Update(ctx, userID, fn); err != nil {
+		log.Printf("update session error: %v", err)
+	}
+}
+
+// lastAnalysis loads the user's latest finished analysis from its job; when there is none it
+// tells the chat so and returns nils.
+func (h *Handler) lastAnalysis(ctx context.Context, chatID, userID int64) (*models.Job, *models.Analysis) {
+	s, err := h.sessions.Get(ctx, userID)
+	if err != nil {
+		log.Printf("get session error: %v", err)
+	}
+
+	var job *models.Job
+	if s != nil && s.LastJobID != 0 {
+		if job, err = h.jobs.Get(ctx, s.LastJobID); err != nil {
+			log.Printf("get last job error: %v", err)
+		}
+	}
+	if job == nil || job.UserID != userID || job.Result == nil {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Нет последнего анализа. Сначала запусти анализ."))
+		return nil, nil
+	}
+	return job, job.Result
+}
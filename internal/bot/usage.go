@@ -0,0 +1,129 @@
+package bot
+
+import (
+	"BoardAI/internal/models"
+	"BoardAI/internal/quota"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	usageReportPeriod = 30 * 24 * time.Hour
+	usageReportTop    = 10
+)
+
+// handleUsage serves /usage: the user's tokens and cost today and over the last 30 days.
+func (h *Handler) handleUsage(ctx context.Context, chatID, userID int64) {
+	now := time.Now()
+	today, err := h.usage.ByUser(ctx, userID, quota.StartOfDay(now))
+	if err != nil {
+		log.Printf("user usage error: %v", err)
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Не удалось получить статистику."))
+		return
+	}
+	month, err := h.usage.ByUser(ctx, userID, now.Add(-usageReportPeriod))
+	if err != nil {
+		log.Printf("user usage error: %v", err)
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Не удалось получить статистику."))
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("📊 Расход токенов\n\n")
+	fmt.Fprintf(&sb, "Сегодня: %s\n", formatUsage(today))
+	fmt.Fprintf(&sb, "За 30 дней: %s", formatUsage(month))
+	if st, err := h.quota.Check(ctx, userID); err != nil {
+		log.Printf("quota check error: %v", err)
+	} else if st.Exempt {
+		sb.WriteString("\n\nКвоты на анализы к вам не применяются.")
+	} else if left := renderQuotaLeft(st, 0); left != "" {
+		sb.WriteString("\n\n" + left)
+	}
+	h.bot.Send(tgbotapi.NewMessage(chatID, sb.String()))
+}
+
+// handleUsageReport serves /usage_report for admins: totals, top users and models over 30 days.
+func (h *Handler) handleUsageReport(ctx context.Context, chatID, userID int64) {
+	if !h.isAdmin(userID) {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Команда доступна только администраторам."))
+		return
+	}
+
+	since := time.Now().Add(-usageReportPeriod)
+	total, err := h.usage.Total(ctx, since)
+	if err != nil {
+		log.Printf("total usage error: %v", err)
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Не удалось построить отчет."))
+		return
+	}
+	users, err := h.usage.TopUsers(ctx, since, usageReportTop)
+	if err != nil {
+		log.Printf("top users usage error: %v", err)
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Не удалось построить отчет."))
+		return
+	}
+	byModel, err := h.usage.ByModel(ctx, since)
+	if err != nil {
+		log.Printf("model usage error: %v", err)
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Не удалось построить отчет."))
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString("📈 Отчет по расходу за 30 дней\n\n")
+	fmt.Fprintf(&sb, "Всего: %s\n", formatUsage(total))
+
+	if len(users) > 0 {
+		sb.WriteString("\nТоп пользователей:\n")
+		for i, u := range users {
+			fmt.Fprintf(&sb, "%d. %d — %s\n", i+1, u.UserID, formatUsage(u))
+		}
+	}
+	if len(byModel) > 0 {
+		sb.WriteString("\nПо моделям:\n")
+		for _, m := range byModel {
+			model := m.Model
+			if model == "" {
+				model = "(неизвестна)"
+			}
+			fmt.Fprintf(&sb, "• %s — %d вызовов, %s ток., $%.4f\n", model, m.Calls, formatTokens(m.TotalTokens()), m.CostUSD)
+		}
+	}
+
+	h.sendLongText(chatID, sb.String(), nil)
+}
+
+func formatUsage(s *models.UsageSummary) string {
+	if s.Calls == 0 {
+		return "нет запросов"
+	}
+	return fmt.Sprintf("%d анализ(ов), %s ток. (%s на входе, %s на выходе), $%.4f",
+		s.Analyses, formatTokens(s.TotalTokens()), formatTokens(s.PromptTokens), formatTokens(s.CompletionTokens), s.CostUSD)
+}
+
+// formatTokens сокращает большие числа: 1234567 → 1.23M.
+func formatTokens(n int64) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.2fM", float64(n)/1_000_000)
+	case n >= 10_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1_000)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
+
+// recordUsage returns a callback that writes LLM calls made outside a board run (follow-up answers,
+// the clarification interview) to the usage ledger; analysisID is 0 when there is no analysis yet.
+func (h *Handler) recordUsage(ctx context.Context, analysisID, userID int64) func(models.AgentRun) {
+	return func(run models.AgentRun) {
+		if err := h.usage.Record(ctx, analysisID, userID, run); err != nil {
+			log.Printf("record %s usage error: %v", run.Stage, err)
+		}
+	}
+}
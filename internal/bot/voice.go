@@ -0,0 +1,151 @@
+package bot
+
+import (
+	"BoardAI/internal/document"
+	"BoardAI/internal/state"
+	"context"
+	"fmt"
+	"log"
+	"mime"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	// maxVoiceDuration — голосовые длиннее распознаются слишком долго, проще написать текстом.
+	maxVoiceDuration = 10 * time.Minute
+	// transcribeTimeout ограничивает распознавание одного сообщения.
+	transcribeTimeout = 5 * time.Minute
+)
+
+// voiceFile is what the bot needs from a voice note or an audio file.
+type voiceFile struct {
+	fileID   string
+	name     string
+	duration time.Duration
+	size     int
+}
+
+// handleVoice transcribes a voice note or an audio file and asks the user to confirm the text
+// before it goes to the board. Recognition runs in the background so other updates are not held up.
+func (h *Handler) handleVoice(ctx context.Context, msg *tgbotapi.Message) {
+	chatID, userID := msg.Chat.ID, msg.From.ID
+
+	if h.transcriber == nil {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Голосовые сообщения не поддерживаются: распознавание речи не настроено. Опишите идею текстом."))
+		return
+	}
+
+	f := voiceFileOf(msg)
+	if f.duration > maxVoiceDuration {
+		h.bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("Голосовое длиннее %d минут я не распознаю. Запишите покороче или опишите идею текстом.", int(maxVoiceDuration.Minutes()))))
+		return
+	}
+	if f.size > document.MaxFileSize {
+		h.bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("Файл больше %d МБ — Telegram не дает боту его скачать.", document.MaxFileSize>>20)))
+		return
+	}
+	// Распознавать незачем, если анализ все равно не запустится.
+	if _, ok := h.checkQuota(ctx, chatID, userID); !ok {
+		return
+	}
+
+	if _, busy := h.transcribing.LoadOrStore(userID, struct{}{}); busy {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Еще распознаю предыдущее голосовое, подождите."))
+		return
+	}
+
+	startState := h.userState(ctx, userID)
+	h.bot.Request(tgbotapi.NewChatAction(chatID, tgbotapi.ChatTyping))
+	sent, _ := h.bot.Send(tgbotapi.NewMessage(chatID, "🎙 Распознаю голосовое сообщение..."))
+
+	go func() {
+		defer h.transcribing.Delete(userID)
+
+		tctx, cancel := context.WithTimeout(ctx, transcribeTimeout)
+		defer cancel()
+
+		data, err := h.downloadFile(tctx, f.fileID, document.MaxFileSize)
+		if err != nil {
+			log.Printf("download voice error: %v", err)
+			h.bot.Send(tgbotapi.NewEditMessageText(chatID, sent.MessageID, "⚠️ Не удалось скачать голосовое. Попробуйте отправить его еще раз."))
+			return
+		}
+		text, err := h.transcriber.Transcribe(tctx, f.name, data)
+		if err != nil {
+			log.Printf("transcribe error: user=%d: %v", userID, err)
+			h.bot.Send(tgbotapi.NewEditMessageText(chatID, sent.MessageID, "⚠️ Не удалось распознать речь. Попробуйте еще раз или опишите идею текстом."))
+			return
+		}
+		if text == "" {
+			h.bot.Send(tgbotapi.NewEditMessageText(chatID, sent.MessageID, "В сообщении не удалось разобрать слов. Запишите его еще раз или опишите идею текстом."))
+			return
+		}
+
+		// Пока речь распознавалась, пользователь мог запустить анализ или начать интервью.
+		var active bool
+		h.updateSession(ctx, userID, func(s *state.Session) {
+			if s.State == startState {
+				s.State = stateConfirmVoice
+				s.PendingTranscript = text
+				active = true
+			}
+		})
+		if !active {
+			h.bot.Send(tgbotapi.NewEditMessageText(chatID, sent.MessageID, "Голосовое распознано, но вы уже перешли к другому действию — расшифровка отброшена."))
+			return
+		}
+
+		h.bot.Request(tgbotapi.NewDeleteMessage(chatID, sent.MessageID))
+		h.sendLongText(chatID, "🎙 Расшифровка:\n\n"+text+
+			"\n\nЗапустить анализ по этому тексту? Если что-то распознано неверно, пришлите исправленный текст сообщением.",
+			buildVoiceConfirmKeyboard())
+	}()
+}
+
+// confirmTranscript starts the analysis of the transcript the user has just approved.
+func (h *Handler) confirmTranscript(ctx context.Context, chatID, userID int64, messageID int) {
+	h.bot.Send(tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, emptyInlineKeyboard()))
+
+	s, err := h.sessions.Get(ctx, userID)
+	if err != nil {
+		log.Printf("get session error: %v", err)
+	}
+	if s == nil || s.State != stateConfirmVoice || s.PendingTranscript == "" {
+		h.bot.Send(tgbotapi.NewMessage(chatID, "Эта расшифровка уже неактуальна. Пришлите голосовое еще раз."))
+		return
+	}
+	h.submitIdea(ctx, chatID, userID, s.PendingTranscript)
+}
+
+// discardTranscript drops the transcript together with the documents waiting for it.
+func (h *Handler) discardTranscript(ctx context.Context, chatID, userID int64, messageID int) {
+	h.bot.Send(tgbotapi.NewEditMessageReplyMarkup(chatID, messageID, emptyInlineKeyboard()))
+	h.updateSession(ctx, userID, func(s *state.Session) {
+		if s.State == stateConfirmVoice {
+			s.State = stateIdle
+			s.PendingAttachments = nil
+		}
+		s.PendingTranscript = ""
+	})
+	resp := tgbotapi.NewMessage(chatID, "Расшифровка отброшена.")
+	resp.ReplyMarkup = buildMainKeyboard()
+	h.bot.Send(resp)
+}
+
+func voiceFileOf(msg *tgbotapi.Message) voiceFile {
+	if v := msg.Voice; v != nil {
+		// Голосовые Telegram всегда записывает в OGG/Opus.
+		return voiceFile{fileID: v.FileID, name: "voice.ogg", duration: time.Duration(v.Duration) * time.Second, size: v.FileSize}
+	}
+	a := msg.Audio
+	name := a.FileName
+	if name == "" {
+		name = "audio"
+		if exts, _ := mime.ExtensionsByType(a.MimeType); len(exts) > 0 {
+			name += exts[0]
+		}
+	}
+	return voiceFile{fileID: a.FileID, name: name, duration: time.Duration(a.Duration) * time.Second, size: a.FileSize}
+}
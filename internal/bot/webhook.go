@@ -0,0 +1,154 @@
+package bot
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// secretTokenHeader is set by Telegram on every webhook request when setWebhook got a secret_token.
+const secretTokenHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+// WebhookConfig describes how the bot receives updates in webhook mode.
+type WebhookConfig struct {
+	// URL — публичный адрес, на который Telegram шлет обновления (без пути).
+	URL string
+	// Listen — адрес, который слушает HTTP-сервер бота, например ":8443".
+	Listen string
+	// PathSecret — случайный сегмент пути, чтобы адрес webhook-а нельзя было угадать.
+	PathSecret string
+	// SecretToken передается в setWebhook и сверяется с заголовком X-Telegram-Bot-Api-Secret-Token.
+	SecretToken string
+	// CertFile и KeyFile включают TLS; самоподписанный сертификат отправляется в setWebhook.
+	CertFile string
+	KeyFile  string
+	// SelfSigned отправляет сертификат в Telegram, чтобы тот доверял самоподписанному TLS.
+	SelfSigned bool
+}
+
+func (c WebhookConfig) path() string {
+	if c.PathSecret == "" {
+		return "/telegram"
+	}
+	return "/telegram/" + c.PathSecret
+}
+
+// RunWebhook registers the webhook and serves updates until ctx is cancelled. Every replica
+// registers the same URL, so any of them may receive an update behind a load balancer.
+func (h *Handler) RunWebhook(ctx context.Context, cfg WebhookConfig) error {
+	if err := h.setWebhook(cfg); err != nil {
+		return err
+	}
+
+	updates := make(chan tgbotapi.Update, 100)
+	mux := http.NewServeMux()
+	mux.HandleFunc(cfg.path(), h.webhookHandler(cfg.SecretToken, updates))
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	srv := &http.Server{
+		Addr:              cfg.Listen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		var err error
+		if cfg.CertFile != "" {
+			err = srv.ListenAndServeTLS(cfg.CertFile, cfg.KeyFile)
+		} else {
+			err = srv.ListenAndServe()
+		}
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+	log.Printf("webhook server listening on %s%s", cfg.Listen, cfg.path())
+
+	// Обновления обрабатываются по одному, как и при long polling.
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("context cancelled, stopping webhook server")
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			return srv.Shutdown(shutdownCtx)
+		case err, ok := <-errCh:
+			if ok {
+				return fmt.Errorf("webhook server: %w", err)
+			}
+			return nil
+		case update := <-updates:
+			h.handleUpdate(ctx, update)
+		}
+	}
+}
+
+// webhookHandler validates the secret token and queues the update; Telegram gets its answer
+// right away instead of waiting for the update to be processed.
+func (h *Handler) webhookHandler(secretToken string, updates chan<- tgbotapi.Update) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if secretToken != "" {
+			got := r.Header.Get(secretTokenHeader)
+			if subtle.ConstantTimeCompare([]byte(got), []byte(secretToken)) != 1 {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+		}
+
+		update, err := h.bot.HandleUpdate(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		select {
+		case updates <- *update:
+			w.WriteHeader(http.StatusOK)
+		default:
+			// Очередь переполнена — Telegram повторит доставку позже.
+			http.Error(w, "busy", http.StatusServiceUnavailable)
+		}
+	}
+}
+
+// setWebhook registers the webhook. The library's WebhookConfig has no secret_token field,
+// so the request is built by hand.
+func (h *Handler) setWebhook(cfg WebhookConfig) error {
+	params := tgbotapi.Params{
+		"url": strings.TrimRight(cfg.URL, "/") + cfg.path(),
+	}
+	params.AddNonEmpty("secret_token", cfg.SecretToken)
+	if err := params.AddInterface("allowed_updates", []string{"message", "callback_query"}); err != nil {
+		return err
+	}
+
+	var err error
+	if cfg.SelfSigned {
+		_, err = h.bot.UploadFiles("setWebhook", params, []tgbotapi.RequestFile{{
+			Name: "certificate",
+			Data: tgbotapi.FilePath(cfg.CertFile),
+		}})
+	} else {
+		_, err = h.bot.MakeRequest("setWebhook", params)
+	}
+	if err != nil {
+		return fmt.Errorf("set webhook: %w", err)
+	}
+
+	info, err := h.bot.GetWebhookInfo()
+	if err == nil && info.LastErrorDate != 0 {
+		log.Printf("webhook last error: %s", info.LastErrorMessage)
+	}
+	return nil
+}
@@ -3,6 +3,12 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"BoardAI/internal/board"
+	"BoardAI/internal/llm"
 )
 
 type Config struct {
@@ -10,12 +16,82 @@ type Config struct {
 	DBURL            string
 	OllamaBaseURL    string
 	OllamaAPIToken   string
+	// OllamaNativeURL — адрес нативного API Ollama (/api/chat) для ролей с provider: ollama.
+	OllamaNativeURL  string
+	AnthropicAPIKey  string
+	AnthropicBaseURL string
+
+	// BoardFile — путь к YAML с описанием совета; пустое значение — встроенный совет.
+	BoardFile string
+	Board     *board.Board
+
+	// MaxParallelAgents ограничивает число экспертов, которые работают одновременно.
+	MaxParallelAgents int
+	// DebateRounds — сколько раундов опровержений проходят эксперты перед вердиктом (0 — без дебатов).
+	DebateRounds int
+
+	// LLMMaxRetries, LLMRetryBaseDelay и LLMRetryMaxDelay задают повторы запросов к LLM
+	// (экспоненциальная задержка с джиттером; Retry-After при 429 имеет приоритет).
+	LLMMaxRetries     int
+	LLMRetryBaseDelay time.Duration
+	LLMRetryMaxDelay  time.Duration
+
+	// JobWorkers — сколько анализов из очереди этот процесс выполняет одновременно.
+	JobWorkers int
+	// JobMaxAttempts — сколько раз задача запускается, прежде чем считается проваленной.
+	JobMaxAttempts int
+
+	// PricesFile — YAML с ценами моделей в USD за миллион токенов; без него все модели бесплатны.
+	PricesFile string
+	Prices     llm.PriceTable
+	// AdminUserIDs — Telegram id администраторов: им доступны отчеты по всем пользователям.
+	AdminUserIDs []int64
+
+	// QuotaMaxConcurrent, QuotaPerDay и QuotaMaxQueue ограничивают анализы в боте (0 — без ограничения).
+	QuotaMaxConcurrent int
+	QuotaPerDay        int
+	QuotaMaxQueue      int
+	// QuotaWhitelist — Telegram id пользователей без квот; администраторы тоже не ограничены.
+	QuotaWhitelist []int64
+
+	// StateStore — где бот хранит состояние диалогов: "postgres" (по умолчанию) или "memory".
+	StateStore string
+	// SessionTTL — через сколько бездействия сессия пользователя сбрасывается.
+	SessionTTL time.Duration
+
+	// InterviewMaxIdeaLength — идеи короче этого числа символов бот сначала уточняет вопросами
+	// к автору (0 — без интервью).
+	InterviewMaxIdeaLength int
+
+	// Transcriber — бэкенд распознавания голосовых сообщений: "whisper" (сервер whisper.cpp, по умолчанию),
+	// "openai" (OpenAI-совместимый /audio/transcriptions) или "none".
+	Transcriber         string
+	TranscriberURL      string
+	TranscriberModel    string
+	TranscriberAPIToken string
+	// TranscriberLanguage — язык речи; пустое значение — автоопределение.
+	TranscriberLanguage string
 
-	ModelStrategist string
-	ModelFinancier  string
-	ModelAuditor    string
-	ModelAnalyst    string
-	ModelModerator  string
+	// PDFFontDir — каталог с DejaVuSans.ttf и DejaVuSans-Bold.ttf для экспорта в PDF.
+	PDFFontDir string
+
+	// TelegramWebhookURL включает режим webhook вместо long polling: публичный адрес бота.
+	TelegramWebhookURL         string
+	TelegramWebhookListen      string
+	TelegramWebhookPathSecret  string
+	TelegramWebhookSecretToken string
+	// TelegramWebhookCert и TelegramWebhookKey — TLS-сертификат и ключ; при TelegramWebhookSelfSigned
+	// сертификат загружается в Telegram.
+	TelegramWebhookCert       string
+	TelegramWebhookKey        string
+	TelegramWebhookSelfSigned bool
+
+	// APIAddr — адрес HTTP API (cmd/api).
+	APIAddr string
+	// APIToken требуется в заголовке Authorization: Bearer <токен>; без него API слушает только localhost.
+	APIToken string
+	// APIWebhookSecret подписывает тело webhook-а HMAC-SHA256 (заголовок X-BoardAI-Signature).
+	APIWebhookSecret string
 }
 
 func Load() (*Config, error) {
@@ -24,27 +100,234 @@ func Load() (*Config, error) {
 		DBURL:            lookupEnvOrDefault("DB_URL", ""),
 		OllamaBaseURL:    lookupEnvOrDefault("OLLAMA_BASE_URL", "http://localhost:11434/v1"),
 		OllamaAPIToken:   lookupEnvOrDefault("OLLAMA_API_TOKEN", ""),
+		AnthropicAPIKey:  lookupEnvOrDefault("ANTHROPIC_API_KEY", ""),
+		AnthropicBaseURL: lookupEnvOrDefault("ANTHROPIC_BASE_URL", ""),
+		BoardFile:        lookupEnvOrDefault("BOARD_FILE", ""),
+		PDFFontDir:       lookupEnvOrDefault("PDF_FONT_DIR", "/usr/share/fonts/truetype/dejavu"),
+		APIAddr:          lookupEnvOrDefault("API_ADDR", "127.0.0.1:8080"),
+		StateStore:       lookupEnvOrDefault("STATE_STORE", "postgres"),
+		PricesFile:       lookupEnvOrDefault("PRICES_FILE", ""),
+
+		Transcriber:         lookupEnvOrDefault("TRANSCRIBER", "whisper"),
+		TranscriberURL:      lookupEnvOrDefault("TRANSCRIBER_URL", "http://localhost:8081"),
+		TranscriberModel:    lookupEnvOrDefault("TRANSCRIBER_MODEL", ""),
+		TranscriberAPIToken: lookupEnvOrDefault("TRANSCRIBER_API_TOKEN", ""),
+		TranscriberLanguage: lookupEnvOrDefault("TRANSCRIBER_LANGUAGE", "ru"),
+
+		TelegramWebhookURL:         lookupEnvOrDefault("TELEGRAM_WEBHOOK_URL", ""),
+		TelegramWebhookListen:      lookupEnvOrDefault("TELEGRAM_WEBHOOK_LISTEN", ":8443"),
+		TelegramWebhookPathSecret:  lookupEnvOrDefault("TELEGRAM_WEBHOOK_PATH_SECRET", ""),
+		TelegramWebhookSecretToken: lookupEnvOrDefault("TELEGRAM_WEBHOOK_SECRET_TOKEN", ""),
+		TelegramWebhookCert:        lookupEnvOrDefault("TELEGRAM_WEBHOOK_CERT", ""),
+		TelegramWebhookKey:         lookupEnvOrDefault("TELEGRAM_WEBHOOK_KEY", ""),
+		APIToken:                   lookupEnvOrDefault("API_TOKEN", ""),
+		APIWebhookSecret:           lookupEnvOrDefault("API_WEBHOOK_SECRET", ""),
+	}
+	cfg.OllamaNativeURL = lookupEnvOrDefault("OLLAMA_NATIVE_URL", strings.TrimSuffix(strings.TrimRight(cfg.OllamaBaseURL, "/"), "/v1"))
 
-		ModelStrategist: lookupEnvOrDefault("MODEL_STRATEGIST", "llama3:8b"),
-		ModelFinancier:  lookupEnvOrDefault("MODEL_FINANCIER", "gemma2:9b"),
-		ModelAuditor:    lookupEnvOrDefault("MODEL_AUDITOR", "mistral:7b"),
-		ModelAnalyst:    lookupEnvOrDefault("MODEL_ANALYST", "qwen2.5:7b"),
-		ModelModerator:  lookupEnvOrDefault("MODEL_MODERATOR", "llama3.1:8b"),
+	cfg.Board = board.Default()
+	if cfg.BoardFile != "" {
+		b, err := board.Load(cfg.BoardFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Board = b
 	}
+	// MODEL_<ID> (например, MODEL_STRATEGIST) по-прежнему переопределяет модель роли.
+	cfg.Board.OverrideModels(func(key string) string {
+		return lookupEnvOrDefault(key, "")
+	})
 
-	if cfg.TelegramBotToken == "" {
-		return nil, fmt.Errorf("TELEGRAM_BOT_TOKEN is required")
+	maxParallel, err := lookupEnvIntOrDefault("MAX_PARALLEL_AGENTS", 1)
+	if err != nil {
+		return nil, err
 	}
-	if cfg.DBURL == "" {
-		return nil, fmt.Errorf("DB_URL is required")
+	if maxParallel < 1 {
+		return nil, fmt.Errorf("MAX_PARALLEL_AGENTS must be >= 1, got %d", maxParallel)
+	}
+	cfg.MaxParallelAgents = maxParallel
+
+	debateRounds, err := lookupEnvIntOrDefault("DEBATE_ROUNDS", 0)
+	if err != nil {
+		return nil, err
+	}
+	if debateRounds < 0 {
+		return nil, fmt.Errorf("DEBATE_ROUNDS must be >= 0, got %d", debateRounds)
+	}
+	cfg.DebateRounds = debateRounds
+
+	if cfg.LLMMaxRetries, err = lookupEnvIntOrDefault("LLM_MAX_RETRIES", 3); err != nil {
+		return nil, err
+	}
+	if cfg.LLMMaxRetries < 0 {
+		return nil, fmt.Errorf("LLM_MAX_RETRIES must be >= 0, got %d", cfg.LLMMaxRetries)
+	}
+	if cfg.LLMRetryBaseDelay, err = lookupEnvDurationOrDefault("LLM_RETRY_BASE_DELAY", time.Second); err != nil {
+		return nil, err
+	}
+	if cfg.LLMRetryMaxDelay, err = lookupEnvDurationOrDefault("LLM_RETRY_MAX_DELAY", 30*time.Second); err != nil {
+		return nil, err
+	}
+
+	if cfg.TelegramWebhookSelfSigned, err = lookupEnvBoolOrDefault("TELEGRAM_WEBHOOK_SELF_SIGNED", false); err != nil {
+		return nil, err
+	}
+	if (cfg.TelegramWebhookCert == "") != (cfg.TelegramWebhookKey == "") {
+		return nil, fmt.Errorf("TELEGRAM_WEBHOOK_CERT and TELEGRAM_WEBHOOK_KEY must be set together")
+	}
+	if cfg.TelegramWebhookSelfSigned && cfg.TelegramWebhookCert == "" {
+		return nil, fmt.Errorf("TELEGRAM_WEBHOOK_SELF_SIGNED requires TELEGRAM_WEBHOOK_CERT")
+	}
+	// Telegram допускает в secret_token только A-Z, a-z, 0-9, _ и -, до 256 символов.
+	if t := cfg.TelegramWebhookSecretToken; len(t) > 256 || strings.Trim(t, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789_-") != "" {
+		return nil, fmt.Errorf("TELEGRAM_WEBHOOK_SECRET_TOKEN may contain only A-Z, a-z, 0-9, _ and -, up to 256 characters")
+	}
+
+	if cfg.JobWorkers, err = lookupEnvIntOrDefault("JOB_WORKERS", 1); err != nil {
+		return nil, err
+	}
+	if cfg.JobWorkers < 1 {
+		return nil, fmt.Errorf("JOB_WORKERS must be >= 1, got %d", cfg.JobWorkers)
+	}
+	if cfg.JobMaxAttempts, err = lookupEnvIntOrDefault("JOB_MAX_ATTEMPTS", 3); err != nil {
+		return nil, err
+	}
+	if cfg.JobMaxAttempts < 1 {
+		return nil, fmt.Errorf("JOB_MAX_ATTEMPTS must be >= 1, got %d", cfg.JobMaxAttempts)
+	}
+
+	if cfg.PricesFile != "" {
+		prices, err := llm.LoadPrices(cfg.PricesFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Prices = prices
+	}
+	if cfg.AdminUserIDs, err = lookupEnvIDs("ADMIN_USER_IDS"); err != nil {
+		return nil, err
+	}
+
+	if cfg.QuotaMaxConcurrent, err = lookupEnvIntOrDefault("QUOTA_MAX_CONCURRENT", 1); err != nil {
+		return nil, err
+	}
+	if cfg.QuotaPerDay, err = lookupEnvIntOrDefault("QUOTA_PER_DAY", 10); err != nil {
+		return nil, err
+	}
+	if cfg.QuotaMaxQueue, err = lookupEnvIntOrDefault("QUOTA_MAX_QUEUE", 50); err != nil {
+		return nil, err
+	}
+	if cfg.QuotaMaxConcurrent < 0 || cfg.QuotaPerDay < 0 || cfg.QuotaMaxQueue < 0 {
+		return nil, fmt.Errorf("QUOTA_MAX_CONCURRENT, QUOTA_PER_DAY and QUOTA_MAX_QUEUE must be >= 0")
+	}
+	if cfg.QuotaWhitelist, err = lookupEnvIDs("QUOTA_WHITELIST"); err != nil {
+		return nil, err
+	}
+
+	if cfg.InterviewMaxIdeaLength, err = lookupEnvIntOrDefault("INTERVIEW_MAX_IDEA_LENGTH", 400); err != nil {
+		return nil, err
+	}
+	if cfg.InterviewMaxIdeaLength < 0 {
+		return nil, fmt.Errorf("INTERVIEW_MAX_IDEA_LENGTH must be >= 0, got %d", cfg.InterviewMaxIdeaLength)
+	}
+
+	switch cfg.Transcriber {
+	case "whisper", "openai", "none":
+	default:
+		return nil, fmt.Errorf("TRANSCRIBER must be whisper, openai or none, got %q", cfg.Transcriber)
+	}
+
+	if cfg.StateStore != "postgres" && cfg.StateStore != "memory" {
+		return nil, fmt.Errorf("STATE_STORE must be postgres or memory, got %q", cfg.StateStore)
+	}
+	if cfg.SessionTTL, err = lookupEnvDurationOrDefault("SESSION_TTL", 24*time.Hour); err != nil {
+		return nil, err
+	}
+	if cfg.SessionTTL <= 0 {
+		return nil, fmt.Errorf("SESSION_TTL must be > 0, got %s", cfg.SessionTTL)
 	}
 
 	return cfg, nil
 }
 
+// IsAdmin reports whether the Telegram user is listed in ADMIN_USER_IDS.
+func (c *Config) IsAdmin(userID int64) bool {
+	for _, id := range c.AdminUserIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// QuotaExempt reports whether the user bypasses the analysis quotas: admins and QUOTA_WHITELIST.
+func (c *Config) QuotaExempt(userID int64) bool {
+	if c.IsAdmin(userID) {
+		return true
+	}
+	for _, id := range c.QuotaWhitelist {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
 func lookupEnvOrDefault(key, def string) string {
 	if val, ok := os.LookupEnv(key); ok {
 		return val
 	}
 	return def
 }
+
+func lookupEnvIntOrDefault(key string, def int) (int, error) {
+	val, ok := os.LookupEnv(key)
+	if !ok || val == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be an integer: %w", key, err)
+	}
+	return n, nil
+}
+
+func lookupEnvDurationOrDefault(key string, def time.Duration) (time.Duration, error) {
+	val, ok := os.LookupEnv(key)
+	if !ok || val == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a duration like 1s or 500ms: %w", key, err)
+	}
+	return d, nil
+}
+
+// lookupEnvIDs parses a comma-separated list of numeric ids; an unset variable means none.
+func lookupEnvIDs(key string) ([]int64, error) {
+	var ids []int64
+	for _, f := range strings.Split(os.Getenv(key), ",") {
+		f = strings.TrimSpace(f)
+		if f == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(f, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a comma-separated list of user ids: %w", key, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func lookupEnvBoolOrDefault(key string, def bool) (bool, error) {
+	val, ok := os.LookupEnv(key)
+	if !ok || val == "" {
+		return def, nil
+	}
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false: %w", key, err)
+	}
+	return b, nil
+}
@@ -0,0 +1,155 @@
+// Package document extracts text and tables from the files users attach to their ideas.
+// Everything is parsed locally: PDF with a pure-Go reader, DOCX and XLSX straight from their
+// zipped XML.
+package document
+
+import (
+	"BoardAI/internal/models"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// MaxFileSize совпадает с лимитом Bot API на скачивание файлов.
+	MaxFileSize = 20 << 20
+	// maxTextRunes ограничивает извлеченный текст: больше ни одна модель совета все равно не прочитает.
+	maxTextRunes = 60000
+	// maxTableRows и maxTableCols ограничивают каждую таблицу.
+	maxTableRows = 300
+	maxTableCols = 30
+	// maxZipEntrySize ограничивает распакованный XML внутри DOCX и XLSX: MaxFileSize касается
+	// только сжатого файла, а zip-бомба в 20 МБ разворачивается в гигабайты.
+	maxZipEntrySize = 64 << 20
+)
+
+// ErrUnsupported is returned for files of a format the bot cannot read.
+var ErrUnsupported = errors.New("unsupported document format")
+
+// Extract converts the file to an attachment; the format is chosen by the file extension.
+func Extract(name string, data []byte) (*models.Attachment, error) {
+	if len(data) > MaxFileSize {
+		return nil, fmt.Errorf("document %s is larger than %d MB", name, MaxFileSize>>20)
+	}
+
+	a := &models.Attachment{Name: name}
+	var err error
+	// cut — разбор остановлен на лимите раньше конца файла.
+	var cut bool
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".pdf":
+		a.Kind = models.AttachmentPDF
+		a.Text, err = extractPDF(data)
+	case ".docx":
+		a.Kind = models.AttachmentDOCX
+		a.Text, cut, err = extractDOCX(data)
+	case ".xlsx":
+		a.Kind = models.AttachmentXLSX
+		a.Tables, cut, err = extractXLSX(data)
+	case ".csv":
+		a.Kind = models.AttachmentCSV
+		var t *models.Table
+		t, err = extractCSV(name, data)
+		if t != nil {
+			a.Tables = []models.Table{*t}
+		}
+	case ".txt", ".md":
+		a.Kind = models.AttachmentText
+		if !utf8.Valid(data) {
+			return nil, fmt.Errorf("document %s is not UTF-8 text", name)
+		}
+		a.Text = string(data)
+	default:
+		return nil, ErrUnsupported
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", name, err)
+	}
+
+	a.Truncated = cut
+	a.Text = normalizeSpace(a.Text)
+	if rs := []rune(a.Text); len(rs) > maxTextRunes {
+		a.Text = string(rs[:maxTextRunes])
+		a.Truncated = true
+	}
+	for i := range a.Tables {
+		if limitTable(&a.Tables[i]) {
+			a.Truncated = true
+		}
+	}
+	if a.Text == "" && len(a.Tables) == 0 {
+		return nil, fmt.Errorf("document %s contains no text", name)
+	}
+	return a, nil
+}
+
+// Supported reports whether Extract can read a file with this name.
+func Supported(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".pdf", ".docx", ".xlsx", ".csv", ".txt", ".md":
+		return true
+	}
+	return false
+}
+
+// RenderTable formats the table as text, one row per line with cells separated by " | ".
+func RenderTable(t models.Table) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Таблица «%s»:\n", t.Name)
+	for _, row := range t.Rows {
+		sb.WriteString(strings.Join(row, " | "))
+		sb.WriteByte('\n')
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// limitTable drops empty rows and trailing empty cells and cuts the table to the limits;
+// it reports whether anything but empty cells was cut.
+func limitTable(t *models.Table) bool {
+	cut := false
+	rows := t.Rows[:0]
+	for _, row := range t.Rows {
+		for len(row) > 0 && strings.TrimSpace(row[len(row)-1]) == "" {
+			row = row[:len(row)-1]
+		}
+		if len(row) == 0 {
+			continue
+		}
+		if len(row) > maxTableCols {
+			row = row[:maxTableCols]
+			cut = true
+		}
+		if len(rows) == maxTableRows {
+			cut = true
+			break
+		}
+		rows = append(rows, row)
+	}
+	t.Rows = rows
+	return cut
+}
+
+// normalizeSpace trims every line and collapses runs of blank lines.
+func normalizeSpace(s string) string {
+	var sb strings.Builder
+	blank := 0
+	for _, line := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			blank++
+			continue
+		}
+		if sb.Len() > 0 {
+			if blank > 0 {
+				sb.WriteString("\n\n")
+			} else {
+				sb.WriteByte('\n')
+			}
+		}
+		blank = 0
+		sb.WriteString(line)
+	}
+	return sb.String()
+}
@@ -0,0 +1,131 @@
+package document
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// extractDOCX reads word/document.xml: paragraphs become lines and table rows become lines with
+// cells separated by " | ". Reading stops once there is more text than Extract keeps; the flag
+// reports that.
+func extractDOCX(data []byte) (string, bool, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", false, err
+	}
+	f, err := openZip(zr, "word/document.xml")
+	if err != nil {
+		return "", false, err
+	}
+	defer f.Close()
+
+	var sb bytes.Buffer
+	runes := 0
+	cellDepth := 0
+	inText := false
+	dec := xml.NewDecoder(f)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", false, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				sb.WriteByte('\t')
+			case "br", "cr":
+				sb.WriteByte('\n')
+			case "tc":
+				cellDepth++
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				// Абзацы внутри ячейки склеиваем, чтобы строка таблицы осталась одной строкой.
+				if cellDepth > 0 {
+					sb.WriteByte(' ')
+				} else {
+					sb.WriteByte('\n')
+				}
+			case "tc":
+				cellDepth--
+				trimRight(&sb, " ")
+				sb.WriteString(" | ")
+			case "tr":
+				trimRight(&sb, " |")
+				sb.WriteByte('\n')
+			case "tbl":
+				sb.WriteByte('\n')
+			}
+		case xml.CharData:
+			if !inText {
+				continue
+			}
+			sb.Write(t)
+			if runes += utf8.RuneCount(t); runes > maxTextRunes {
+				return sb.String(), true, nil
+			}
+		}
+	}
+	return sb.String(), false, nil
+}
+
+// trimRight drops the trailing characters of buf that are in cutset.
+func trimRight(buf *bytes.Buffer, cutset string) {
+	buf.Truncate(len(bytes.TrimRight(buf.Bytes(), cutset)))
+}
+
+// openZip opens the named entry of the archive; reading it fails past maxZipEntrySize bytes.
+func openZip(zr *zip.Reader, name string) (io.ReadCloser, error) {
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+		if f.UncompressedSize64 > maxZipEntrySize {
+			return nil, errEntryTooLarge(name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		// Размер в заголовке архива может быть подделан, поэтому считаем байты и при чтении.
+		return &zipEntry{ReadCloser: rc, name: name, left: maxZipEntrySize}, nil
+	}
+	return nil, fmt.Errorf("%s not found", name)
+}
+
+// zipEntry reads an archive entry of at most left more bytes.
+type zipEntry struct {
+	io.ReadCloser
+	name string
+	left int64
+}
+
+func (e *zipEntry) Read(p []byte) (int, error) {
+	if e.left <= 0 {
+		return 0, errEntryTooLarge(e.name)
+	}
+	if int64(len(p)) > e.left {
+		p = p[:e.left]
+	}
+	n, err := e.ReadCloser.Read(p)
+	e.left -= int64(n)
+	return n, err
+}
+
+func errEntryTooLarge(name string) error {
+	return fmt.Errorf("%s is larger than %d MB unpacked", name, maxZipEntrySize>>20)
+}
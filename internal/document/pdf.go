@@ -0,0 +1,47 @@
+package document
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/ledongthuc/pdf"
+)
+
+// extractPDF reads the text layer page by page; scanned PDFs without one come out empty.
+func extractPDF(data []byte) (text string, err error) {
+	// Разбор битых PDF может паниковать внутри библиотеки.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("broken pdf: %v", r)
+		}
+	}()
+
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for i := 1; i <= r.NumPage(); i++ {
+		p := r.Page(i)
+		if p.V.IsNull() {
+			continue
+		}
+		rows, err := p.GetTextByRow()
+		if err != nil {
+			return "", fmt.Errorf("page %d: %w", i, err)
+		}
+		for _, row := range rows {
+			var words []string
+			for _, t := range row.Content {
+				words = append(words, t.S)
+			}
+			sb.WriteString(strings.Join(words, " "))
+			sb.WriteByte('\n')
+		}
+		// Пустая строка между страницами помогает потом резать документ на куски.
+		sb.WriteByte('\n')
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,231 @@
+package document
+
+import (
+	"BoardAI/internal/models"
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		// r:id ссылается на файл листа через workbook.xml.rels.
+		RID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRels struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxText is the text of a shared or inline string: either a single <t> or rich-text runs.
+type xlsxText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var sb strings.Builder
+	for _, r := range t.Runs {
+		sb.WriteString(r.T)
+	}
+	return sb.String()
+}
+
+type xlsxRow struct {
+	Cells []struct {
+		Ref    string   `xml:"r,attr"`
+		Type   string   `xml:"t,attr"`
+		Value  string   `xml:"v"`
+		Inline xlsxText `xml:"is"`
+	} `xml:"c"`
+}
+
+// empty reports whether the row has no values; such rows are dropped by limitTable anyway.
+func (r xlsxRow) empty() bool {
+	for _, c := range r.Cells {
+		if strings.TrimSpace(c.Value) != "" || c.Inline.String() != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// extractXLSX reads every sheet into a table of cell values. Formulas are represented by the
+// values Excel cached when the file was saved. A sheet is read only up to the rows Extract keeps;
+// the flag reports that some rows were left unread.
+func extractXLSX(data []byte) ([]models.Table, bool, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, false, err
+	}
+
+	var wb xlsxWorkbook
+	if err := decodeZipXML(zr, "xl/workbook.xml", &wb); err != nil {
+		return nil, false, err
+	}
+	var rels xlsxRels
+	if err := decodeZipXML(zr, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, false, err
+	}
+	targets := make(map[string]string, len(rels.Relationships))
+	for _, r := range rels.Relationships {
+		target := strings.TrimPrefix(r.Target, "/")
+		if !strings.HasPrefix(target, "xl/") {
+			target = path.Join("xl", target)
+		}
+		targets[r.ID] = target
+	}
+
+	// Общих строк может не быть, если в книге одни числа.
+	var shared struct {
+		Items []xlsxText `xml:"si"`
+	}
+	_ = decodeZipXML(zr, "xl/sharedStrings.xml", &shared)
+
+	var tables []models.Table
+	cut := false
+	for _, s := range wb.Sheets {
+		target, ok := targets[s.RID]
+		if !ok {
+			continue
+		}
+		rows, more, err := readSheetRows(zr, target, maxTableRows)
+		if err != nil {
+			return nil, false, err
+		}
+		cut = cut || more
+
+		t := models.Table{Name: s.Name}
+		for _, row := range rows {
+			var cells []string
+			for i, c := range row.Cells {
+				col := columnIndex(c.Ref)
+				if col < 0 {
+					col = i
+				}
+				if col >= maxTableCols {
+					continue
+				}
+				for len(cells) <= col {
+					cells = append(cells, "")
+				}
+
+				v := c.Value
+				switch c.Type {
+				case "s":
+					if n, err := strconv.Atoi(v); err == nil && n >= 0 && n < len(shared.Items) {
+						v = shared.Items[n].String()
+					}
+				case "inlineStr":
+					v = c.Inline.String()
+				case "b":
+					if v == "1" {
+						v = "TRUE"
+					} else {
+						v = "FALSE"
+					}
+				}
+				cells[col] = strings.TrimSpace(v)
+			}
+			t.Rows = append(t.Rows, cells)
+		}
+		tables = append(tables, t)
+	}
+	return tables, cut, nil
+}
+
+// readSheetRows decodes the rows of a sheet one at a time and stops after limit non-empty rows;
+// the flag reports whether the sheet has more of them.
+func readSheetRows(zr *zip.Reader, name string, limit int) ([]xlsxRow, bool, error) {
+	f, err := openZip(zr, name)
+	if err != nil {
+		return nil, false, err
+	}
+	defer f.Close()
+
+	var rows []xlsxRow
+	dec := xml.NewDecoder(f)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return rows, false, nil
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "row" {
+			continue
+		}
+		var row xlsxRow
+		if err := dec.DecodeElement(&row, &start); err != nil {
+			return nil, false, err
+		}
+		if row.empty() {
+			continue
+		}
+		if len(rows) == limit {
+			return rows, true, nil
+		}
+		rows = append(rows, row)
+	}
+}
+
+// columnIndex converts the column letters of a cell reference like "AB12" to a zero-based index.
+func columnIndex(ref string) int {
+	col := 0
+	n := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+		n++
+	}
+	if n == 0 {
+		return -1
+	}
+	return col - 1
+}
+
+func decodeZipXML(zr *zip.Reader, name string, v any) error {
+	f, err := openZip(zr, name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return xml.NewDecoder(f).Decode(v)
+}
+
+// extractCSV reads a comma- or semicolon-separated file (Excel in the Russian locale saves the latter).
+func extractCSV(name string, data []byte) (*models.Table, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		r.Comma = ';'
+	}
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	return &models.Table{Name: strings.TrimSuffix(name, path.Ext(name)), Rows: rows}, nil
+}
@@ -0,0 +1,162 @@
+package export
+
+import (
+	"BoardAI/internal/board"
+	"BoardAI/internal/models"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Format is an export file format.
+type Format string
+
+const (
+	FormatMarkdown Format = "md"
+	FormatHTML     Format = "html"
+	FormatPDF      Format = "pdf"
+)
+
+// Formats lists the supported formats in the order they are offered to users.
+var Formats = []Format{FormatPDF, FormatMarkdown, FormatHTML}
+
+// Title returns a short label for the format.
+func (f Format) Title() string {
+	switch f {
+	case FormatMarkdown:
+		return "Markdown"
+	case FormatHTML:
+		return "HTML"
+	case FormatPDF:
+		return "PDF"
+	}
+	return string(f)
+}
+
+// ParseFormat validates a format name.
+func ParseFormat(s string) (Format, error) {
+	f := Format(strings.ToLower(strings.TrimPrefix(s, ".")))
+	for _, known := range Formats {
+		if f == known {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown export format %q", s)
+}
+
+// Exporter renders analyses into board report files.
+type Exporter struct {
+	fontDir string
+}
+
+// NewExporter creates an exporter. fontDir must contain DejaVuSans.ttf and DejaVuSans-Bold.ttf;
+// they are only needed for PDF, whose built-in fonts have no Cyrillic.
+func NewExporter(fontDir string) *Exporter {
+	return &Exporter{fontDir: fontDir}
+}
+
+// Render renders the analysis in the given format.
+func (e *Exporter) Render(a *models.Analysis, b *board.Board, f Format) ([]byte, error) {
+	doc := newDocument(a, b)
+	switch f {
+	case FormatMarkdown:
+		return renderMarkdown(doc), nil
+	case FormatHTML:
+		return renderHTML(doc)
+	case FormatPDF:
+		return renderPDF(doc, e.fontDir)
+	}
+	return nil, fmt.Errorf("unknown export format %q", f)
+}
+
+// FileName returns the name the report file is sent under.
+func FileName(a *models.Analysis, f Format) string {
+	if a.ID == 0 {
+		return fmt.Sprintf("board-report.%s", f)
+	}
+	return fmt.Sprintf("board-report-%d.%s", a.ID, f)
+}
+
+// document is the format-independent layout of a board report.
+type document struct {
+	Title     string
+	Number    int64
+	Idea      string
+	CreatedAt time.Time
+	// Brief — бриф по ответам автора на уточняющие вопросы, если они были.
+	Brief string
+
+	Verdict *verdictSection
+	// Moderator — текстовый вердикт, когда структурированного нет.
+	Moderator string
+	Sections  []section
+}
+
+type verdictSection struct {
+	Decision        string
+	Score           int
+	Summary         string
+	Dimensions      []score
+	Risks           []string
+	Recommendations []string
+}
+
+type score struct {
+	Title string
+	Value int
+}
+
+type section struct {
+	Title   string
+	Score   *int
+	Content string
+}
+
+func newDocument(a *models.Analysis, b *board.Board) *document {
+	doc := &document{
+		Title:     "Заключение совета директоров",
+		Number:    a.ID,
+		Idea:      a.IdeaText,
+		Brief:     a.Brief,
+		CreatedAt: a.CreatedAt,
+		Moderator: a.Moderator,
+	}
+	if doc.CreatedAt.IsZero() {
+		doc.CreatedAt = time.Now()
+	}
+
+	if v := a.Verdict; v != nil {
+		vs := &verdictSection{
+			Decision:        v.Decision.Title(),
+			Score:           v.Score,
+			Summary:         v.Summary,
+			Risks:           v.Risks,
+			Recommendations: v.Recommendations,
+		}
+		for _, d := range models.VerdictDimensions {
+			if s, ok := v.Dimensions[d]; ok {
+				vs.Dimensions = append(vs.Dimensions, score{Title: models.DimensionTitle(d), Value: s})
+			}
+		}
+		doc.Verdict = vs
+	}
+
+	for _, r := range a.Reports {
+		s := section{Title: b.Title(r.Role), Content: strings.TrimSpace(r.Content)}
+		if r.Assessment != nil {
+			sc := r.Assessment.Score
+			s.Score = &sc
+		}
+		doc.Sections = append(doc.Sections, s)
+	}
+	return doc
+}
+
+// subtitle is the line under the report title: number and date.
+func (d *document) subtitle() string {
+	date := d.CreatedAt.Format("02.01.2006 15:04")
+	if d.Number == 0 {
+		return date
+	}
+	return fmt.Sprintf("Анализ №%d · %s", d.Number, date)
+}
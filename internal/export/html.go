@@ -0,0 +1,91 @@
+package export
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+)
+
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"paragraphs": paragraphs,
+}).Parse(`<!DOCTYPE html>
+<html lang="ru">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: "DejaVu Sans", Arial, sans-serif; max-width: 800px; margin: 40px auto; color: #222; line-height: 1.5; }
+header { border-bottom: 2px solid #222; margin-bottom: 24px; }
+h1 { margin-bottom: 4px; }
+.subtitle { color: #666; margin-top: 0; }
+blockquote { border-left: 4px solid #ccc; margin: 0; padding: 4px 16px; color: #444; }
+.decision { font-size: 1.3em; font-weight: bold; }
+table { border-collapse: collapse; margin: 12px 0; }
+td { border: 1px solid #ddd; padding: 4px 12px; }
+td.score { text-align: right; }
+section.expert { page-break-inside: avoid; margin-top: 24px; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1>
+<p class="subtitle">{{.Subtitle}}</p>
+</header>
+
+<h2>Идея</h2>
+<blockquote>{{paragraphs .Idea}}</blockquote>
+{{if .Brief}}
+<h2>Бриф</h2>
+{{paragraphs .Brief}}
+{{end}}
+
+<h2>Вердикт</h2>
+{{with .Verdict}}
+<p class="decision">{{.Decision}} — {{.Score}}/100</p>
+{{paragraphs .Summary}}
+{{if .Dimensions}}<table>{{range .Dimensions}}<tr><td>{{.Title}}</td><td class="score">{{.Value}}/100</td></tr>{{end}}</table>{{end}}
+{{if .Risks}}<h3>Ключевые риски</h3><ul>{{range .Risks}}<li>{{.}}</li>{{end}}</ul>{{end}}
+{{if .Recommendations}}<h3>Рекомендации</h3><ul>{{range .Recommendations}}<li>{{.}}</li>{{end}}</ul>{{end}}
+{{else}}
+{{paragraphs .Moderator}}
+{{end}}
+
+<h2>Отчеты экспертов</h2>
+{{range .Sections}}
+<section class="expert">
+<h3>{{.Title}}{{with .Score}} — {{.}}/100{{end}}</h3>
+{{paragraphs .Content}}
+</section>
+{{end}}
+</body>
+</html>
+`))
+
+func renderHTML(d *document) ([]byte, error) {
+	data := struct {
+		*document
+		Subtitle string
+	}{d, d.subtitle()}
+
+	var buf bytes.Buffer
+	if err := htmlTemplate.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// paragraphs escapes text and turns blank-line separated blocks into <p> and line breaks into <br>.
+func paragraphs(s string) template.HTML {
+	var sb strings.Builder
+	for _, p := range strings.Split(strings.TrimSpace(s), "\n\n") {
+		if p = strings.TrimSpace(p); p == "" {
+			continue
+		}
+		lines := strings.Split(p, "\n")
+		for i, l := range lines {
+			lines[i] = template.HTMLEscapeString(l)
+		}
+		sb.WriteString("<p>" + strings.Join(lines, "<br>") + "</p>\n")
+	}
+	return template.HTML(sb.String())
+}
@@ -0,0 +1,62 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+func renderMarkdown(d *document) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# %s\n\n_%s_\n\n", d.Title, d.subtitle())
+	fmt.Fprintf(&buf, "## Идея\n\n%s\n\n", quoteMarkdown(d.Idea))
+	if d.Brief != "" {
+		fmt.Fprintf(&buf, "## Бриф\n\n%s\n\n", d.Brief)
+	}
+
+	buf.WriteString("## Вердикт\n\n")
+	if v := d.Verdict; v != nil {
+		fmt.Fprintf(&buf, "**%s** — оценка **%d/100**\n\n%s\n\n", v.Decision, v.Score, v.Summary)
+		if len(v.Dimensions) > 0 {
+			buf.WriteString("| Направление | Оценка |\n|---|---:|\n")
+			for _, s := range v.Dimensions {
+				fmt.Fprintf(&buf, "| %s | %d/100 |\n", s.Title, s.Value)
+			}
+			buf.WriteString("\n")
+		}
+		writeMarkdownList(&buf, "Ключевые риски", v.Risks)
+		writeMarkdownList(&buf, "Рекомендации", v.Recommendations)
+	} else {
+		fmt.Fprintf(&buf, "%s\n\n", strings.TrimSpace(d.Moderator))
+	}
+
+	buf.WriteString("## Отчеты экспертов\n\n")
+	for _, s := range d.Sections {
+		fmt.Fprintf(&buf, "### %s", s.Title)
+		if s.Score != nil {
+			fmt.Fprintf(&buf, " — %d/100", *s.Score)
+		}
+		fmt.Fprintf(&buf, "\n\n%s\n\n", s.Content)
+	}
+	return buf.Bytes()
+}
+
+func writeMarkdownList(buf *bytes.Buffer, title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(buf, "**%s:**\n\n", title)
+	for _, item := range items {
+		fmt.Fprintf(buf, "- %s\n", item)
+	}
+	buf.WriteString("\n")
+}
+
+// quoteMarkdown оформляет многострочный текст как цитату.
+func quoteMarkdown(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	for i, l := range lines {
+		lines[i] = "> " + l
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,141 @@
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+)
+
+const (
+	pdfFont     = "DejaVu"
+	pdfFontFile = "DejaVuSans.ttf"
+	pdfBoldFile = "DejaVuSans-Bold.ttf"
+)
+
+func renderPDF(d *document, fontDir string) ([]byte, error) {
+	regular, err := os.ReadFile(filepath.Join(fontDir, pdfFontFile))
+	if err != nil {
+		return nil, fmt.Errorf("load pdf font: %w", err)
+	}
+	bold, err := os.ReadFile(filepath.Join(fontDir, pdfBoldFile))
+	if err != nil {
+		return nil, fmt.Errorf("load pdf font: %w", err)
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetTitle(d.Title, true)
+	pdf.AddUTF8FontFromBytes(pdfFont, "", regular)
+	pdf.AddUTF8FontFromBytes(pdfFont, "B", bold)
+
+	pdf.SetMargins(20, 20, 20)
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont(pdfFont, "", 8)
+		pdf.SetTextColor(128, 128, 128)
+		pdf.CellFormat(0, 10, fmt.Sprintf("%s · стр. %d", d.Title, pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+
+	// Обложка
+	pdf.AddPage()
+	pdf.SetY(90)
+	pdf.SetFont(pdfFont, "B", 24)
+	pdf.MultiCell(0, 12, d.Title, "", "C", false)
+	pdf.SetFont(pdfFont, "", 12)
+	pdf.SetTextColor(100, 100, 100)
+	pdf.MultiCell(0, 8, d.subtitle(), "", "C", false)
+	pdf.SetTextColor(0, 0, 0)
+	if v := d.Verdict; v != nil {
+		pdf.Ln(12)
+		pdf.SetFont(pdfFont, "B", 18)
+		pdf.MultiCell(0, 10, fmt.Sprintf("%s — %d/100", v.Decision, v.Score), "", "C", false)
+	}
+
+	pdf.AddPage()
+	pdfHeading(pdf, "Идея")
+	pdfText(pdf, d.Idea)
+	if d.Brief != "" {
+		pdfHeading(pdf, "Бриф")
+		pdfText(pdf, d.Brief)
+	}
+
+	pdfHeading(pdf, "Вердикт")
+	if v := d.Verdict; v != nil {
+		pdf.SetFont(pdfFont, "B", 12)
+		pdf.MultiCell(0, 7, fmt.Sprintf("%s — оценка %d/100", v.Decision, v.Score), "", "L", false)
+		pdf.Ln(2)
+		pdfText(pdf, v.Summary)
+
+		if len(v.Dimensions) > 0 {
+			pdf.SetFont(pdfFont, "", 11)
+			for _, s := range v.Dimensions {
+				pdf.CellFormat(60, 7, s.Title, "1", 0, "L", false, 0, "")
+				pdf.CellFormat(30, 7, fmt.Sprintf("%d/100", s.Value), "1", 1, "R", false, 0, "")
+			}
+			pdf.Ln(4)
+		}
+		pdfList(pdf, "Ключевые риски", v.Risks)
+		pdfList(pdf, "Рекомендации", v.Recommendations)
+	} else {
+		pdfText(pdf, d.Moderator)
+	}
+
+	pdfHeading(pdf, "Отчеты экспертов")
+	for _, s := range d.Sections {
+		title := s.Title
+		if s.Score != nil {
+			title += fmt.Sprintf(" — %d/100", *s.Score)
+		}
+		pdf.SetFont(pdfFont, "B", 12)
+		pdf.MultiCell(0, 7, pdfSafe(title), "", "L", false)
+		pdf.Ln(1)
+		pdfText(pdf, s.Content)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("render pdf: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func pdfHeading(pdf *fpdf.Fpdf, text string) {
+	pdf.Ln(4)
+	pdf.SetFont(pdfFont, "B", 16)
+	pdf.MultiCell(0, 9, text, "", "L", false)
+	pdf.Ln(2)
+}
+
+func pdfText(pdf *fpdf.Fpdf, text string) {
+	pdf.SetFont(pdfFont, "", 11)
+	pdf.MultiCell(0, 6, pdfSafe(text), "", "L", false)
+	pdf.Ln(4)
+}
+
+func pdfList(pdf *fpdf.Fpdf, title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	pdf.SetFont(pdfFont, "B", 11)
+	pdf.MultiCell(0, 6, title+":", "", "L", false)
+	pdf.SetFont(pdfFont, "", 11)
+	for _, item := range items {
+		pdf.MultiCell(0, 6, pdfSafe("• "+item), "", "L", false)
+	}
+	pdf.Ln(4)
+}
+
+// pdfSafe drops emoji and other characters outside the Basic Multilingual Plane, which the
+// UTF-8 font support of fpdf cannot encode, along with the joiners they leave behind.
+func pdfSafe(s string) string {
+	return strings.TrimSpace(strings.Map(func(r rune) rune {
+		if r > 0xFFFF || r == 0xFE0F || r == 0x200D {
+			return -1
+		}
+		return r
+	}, s))
+}
@@ -0,0 +1,212 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultAnthropicBaseURL = "https://api.anthropic.com/v1"
+	anthropicVersion        = "2023-06-01"
+	// Messages API требует max_tokens, поэтому подставляем значение, если роль его не задала.
+	anthropicDefaultMaxTokens = 1024
+)
+
+// AnthropicProvider talks to the Anthropic Messages API.
+type AnthropicProvider struct {
+	httpBackend
+	apiKey string
+}
+
+// NewAnthropicProvider creates the provider; an empty baseURL means DefaultAnthropicBaseURL.
+func NewAnthropicProvider(baseURL, apiKey string, retry RetryPolicy) *AnthropicProvider {
+	if baseURL == "" {
+		baseURL = DefaultAnthropicBaseURL
+	}
+	return &AnthropicProvider{
+		httpBackend: newHTTPBackend(baseURL, retry),
+		apiKey:      apiKey,
+	}
+}
+
+type anthropicRequest struct {
+	Model       string        `json:"model"`
+	System      string        `json:"system,omitempty"`
+	Messages    []ChatMessage `json:"messages"`
+	MaxTokens   int           `json:"max_tokens"`
+	Temperature float32       `json:"temperature"`
+	Stream      bool          `json:"stream,omitempty"`
+}
+
+type anthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	Usage anthropicUsage `json:"usage"`
+}
+
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+func (u anthropicUsage) toUsage(model string, started time.Time) Usage {
+	return Usage{
+		Model:            model,
+		PromptTokens:     u.InputTokens,
+		CompletionTokens: u.OutputTokens,
+		Latency:          time.Since(started),
+	}
+}
+
+// anthropicEvent covers the SSE events we care about: content_block_delta, error and the usage
+// carried by message_start (input tokens) and message_delta (output tokens so far).
+type anthropicEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	Message struct {
+		Usage anthropicUsage `json:"usage"`
+	} `json:"message"`
+	Usage anthropicUsage `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (p *AnthropicProvider) Chat(ctx context.Context, req Request) (Response, error) {
+	started := time.Now()
+	body, prefix := newAnthropicRequest(req, false)
+	resp, err := p.postJSON(ctx, "/messages", p.headers(), body)
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	var parsed anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return Response{}, fmt.Errorf("decode response: %w", err)
+	}
+	usage := parsed.Usage.toUsage(req.Model, started)
+
+	var sb strings.Builder
+	for _, c := range parsed.Content {
+		if c.Type == "text" {
+			sb.WriteString(c.Text)
+		}
+	}
+	if sb.Len() == 0 {
+		return Response{Usage: usage}, fmt.Errorf("empty anthropic response")
+	}
+
+	return Response{Content: prefix + sb.String(), Usage: usage}, nil
+}
+
+func (p *AnthropicProvider) ChatStream(ctx context.Context, req Request, onDelta DeltaFunc) (Response, error) {
+	started := time.Now()
+	body, prefix := newAnthropicRequest(req, true)
+	resp, err := p.postJSON(ctx, "/messages", p.headers(), body)
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	var sb strings.Builder
+	var usage anthropicUsage
+	result := func() Response {
+		return Response{Content: sb.String(), Usage: usage.toUsage(req.Model, started)}
+	}
+
+	sb.WriteString(prefix)
+	if prefix != "" && onDelta != nil {
+		onDelta(prefix)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+events:
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		// Строки "event: ..." дублируют поле type из data, поэтому читаем только data.
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		var ev anthropicEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &ev); err != nil {
+			return result(), fmt.Errorf("decode stream event: %w", err)
+		}
+
+		switch ev.Type {
+		case "message_start":
+			usage.InputTokens = ev.Message.Usage.InputTokens
+		case "message_delta":
+			usage.OutputTokens = ev.Usage.OutputTokens
+		case "content_block_delta":
+			if ev.Delta.Type != "text_delta" || ev.Delta.Text == "" {
+				continue
+			}
+			sb.WriteString(ev.Delta.Text)
+			if onDelta != nil {
+				onDelta(ev.Delta.Text)
+			}
+		case "error":
+			return result(), fmt.Errorf("anthropic stream error: %s: %s", ev.Error.Type, ev.Error.Message)
+		case "message_stop":
+			// Конец сообщения еще не значит, что модель что-то написала: пустой ответ проверяется ниже.
+			break events
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return result(), fmt.Errorf("read stream: %w", err)
+	}
+
+	if sb.Len() == len(prefix) {
+		return result(), fmt.Errorf("empty streamed anthropic response")
+	}
+
+	return result(), nil
+}
+
+func (p *AnthropicProvider) headers() map[string]string {
+	return map[string]string{
+		"x-api-key":         p.apiKey,
+		"anthropic-version": anthropicVersion,
+	}
+}
+
+// newAnthropicRequest converts the request. Messages API has no JSON mode, so for JSON requests
+// the assistant turn is prefilled with "{" and the returned prefix must be prepended to the answer.
+func newAnthropicRequest(r Request, stream bool) (anthropicRequest, string) {
+	system, messages := splitSystem(r.Messages)
+
+	prefix := ""
+	if r.JSON {
+		prefix = "{"
+		messages = append(messages, ChatMessage{Role: "assistant", Content: prefix})
+	}
+
+	maxTokens := r.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = anthropicDefaultMaxTokens
+	}
+
+	return anthropicRequest{
+		Model:       r.Model,
+		System:      system,
+		Messages:    messages,
+		MaxTokens:   maxTokens,
+		Temperature: r.Temperature,
+		Stream:      stream,
+	}, prefix
+}
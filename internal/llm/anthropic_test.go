@@ -0,0 +1,130 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// sse joins Anthropic stream events into an SSE body with the "event:" lines the API sends.
+func sse(events ...string) string {
+	var sb strings.Builder
+	for _, ev := range events {
+		typ := ev[strings.Index(ev, `"type":"`)+len(`"type":"`):]
+		typ = typ[:strings.Index(typ, `"`)]
+		sb.WriteString("event: " + typ + "\ndata: " + ev + "\n\n")
+	}
+	return sb.String()
+}
+
+func TestAnthropicChat(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/messages" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if r.Header.Get("x-api-key") != "key" || r.Header.Get("anthropic-version") != anthropicVersion {
+			t.Errorf("headers = %v", r.Header)
+		}
+
+		var req anthropicRequest
+		decodeBody(t, r, &req)
+		if req.System != "sys" || req.MaxTokens != anthropicDefaultMaxTokens || req.Stream {
+			t.Errorf("request = %+v", req)
+		}
+		// JSON-режим: последний ход ассистента предзаполнен "{".
+		if n := len(req.Messages); n != 2 || req.Messages[0].Role != "user" || req.Messages[1] != (ChatMessage{Role: "assistant", Content: "{"}) {
+			t.Errorf("messages = %+v", req.Messages)
+		}
+
+		io.WriteString(w, `{"content":[{"type":"text","text":"\"a\":1}"}],"usage":{"input_tokens":30,"output_tokens":6}}`)
+	}))
+	defer srv.Close()
+
+	resp, err := NewAnthropicProvider(srv.URL, "key", testRetry).Chat(context.Background(), NewRequest("claude", "sys", "user", Options{JSON: true}))
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if resp.Content != `{"a":1}` {
+		t.Errorf("Content = %q, want the prefill prepended", resp.Content)
+	}
+	if resp.Usage.Model != "claude" || resp.Usage.PromptTokens != 30 || resp.Usage.CompletionTokens != 6 {
+		t.Errorf("Usage = %+v", resp.Usage)
+	}
+}
+
+func TestAnthropicChatStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req anthropicRequest
+		decodeBody(t, r, &req)
+		if !req.Stream || len(req.Messages) != 1 {
+			t.Errorf("stream request = %+v", req)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, sse(
+			`{"type":"message_start","message":{"usage":{"input_tokens":15,"output_tokens":1}}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+			`{"type":"ping"}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Добрый"}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":" день"}}`,
+			`{"type":"content_block_stop","index":0}`,
+			`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":4}}`,
+			`{"type":"message_stop"}`,
+		))
+	}))
+	defer srv.Close()
+
+	var deltas []string
+	resp, err := NewAnthropicProvider(srv.URL, "key", testRetry).ChatStream(context.Background(),
+		NewRequest("claude", "s", "u", Options{}), func(d string) { deltas = append(deltas, d) })
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	if resp.Content != "Добрый день" {
+		t.Errorf("Content = %q", resp.Content)
+	}
+	if strings.Join(deltas, "|") != "Добрый| день" {
+		t.Errorf("deltas = %q", deltas)
+	}
+	if resp.Usage.PromptTokens != 15 || resp.Usage.CompletionTokens != 4 {
+		t.Errorf("Usage = %+v", resp.Usage)
+	}
+}
+
+func TestAnthropicChatStreamEmpty(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, sse(
+			`{"type":"message_start","message":{"usage":{"input_tokens":15,"output_tokens":1}}}`,
+			`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":1}}`,
+			`{"type":"message_stop"}`,
+		))
+	}))
+	defer srv.Close()
+
+	// В JSON-режиме префикс "{" не считается ответом модели.
+	resp, err := NewAnthropicProvider(srv.URL, "key", testRetry).ChatStream(context.Background(), NewRequest("claude", "s", "u", Options{JSON: true}), nil)
+	if err == nil || !strings.Contains(err.Error(), "empty streamed") {
+		t.Fatalf("err = %v, want empty streamed response", err)
+	}
+	if resp.Usage.PromptTokens != 15 {
+		t.Errorf("usage of an empty answer is lost: %+v", resp.Usage)
+	}
+}
+
+func TestAnthropicChatStreamError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, sse(
+			`{"type":"message_start","message":{"usage":{"input_tokens":15,"output_tokens":1}}}`,
+			`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+		))
+	}))
+	defer srv.Close()
+
+	_, err := NewAnthropicProvider(srv.URL, "key", testRetry).ChatStream(context.Background(), NewRequest("claude", "s", "u", Options{}), nil)
+	if err == nil || !strings.Contains(err.Error(), "overloaded_error: Overloaded") {
+		t.Fatalf("err = %v, want the stream error", err)
+	}
+}
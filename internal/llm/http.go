@@ -0,0 +1,70 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// httpBackend holds what every HTTP provider shares: the client and the retry policy.
+type httpBackend struct {
+	baseURL string
+	client  *http.Client
+	retry   RetryPolicy
+}
+
+func newHTTPBackend(baseURL string, retry RetryPolicy) httpBackend {
+	if retry == (RetryPolicy{}) {
+		retry = DefaultRetryPolicy
+	}
+	return httpBackend{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		client: &http.Client{
+			Timeout: 0,
+		},
+		retry: retry,
+	}
+}
+
+// postJSON sends body to baseURL+path with retries and returns the successful response.
+// Non-2xx answers are turned into *StatusError.
+func (b httpBackend) postJSON(ctx context.Context, path string, headers map[string]string, body any) (*http.Response, error) {
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("marshal request: %w", err)
+	}
+
+	return b.retry.withRetry(ctx, func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.baseURL+path, bytes.NewReader(bodyBytes))
+		if err != nil {
+			return nil, fmt.Errorf("new request: %w", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+
+		resp, err := b.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("http do: %w", err)
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+			return nil, &StatusError{
+				Code:       resp.StatusCode,
+				Status:     resp.Status,
+				Body:       strings.TrimSpace(string(body)),
+				RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+			}
+		}
+
+		return resp, nil
+	})
+}
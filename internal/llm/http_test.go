@@ -0,0 +1,102 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testRetry keeps retries fast: one repeat and pauses of a few milliseconds.
+var testRetry = RetryPolicy{MaxRetries: 1, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+
+// decodeBody reads the JSON request body into v.
+func decodeBody(t *testing.T, r *http.Request, v any) {
+	t.Helper()
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Fatalf("read request body: %v", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("decode request body %s: %v", data, err)
+	}
+}
+
+func TestPostJSONStatusError(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.Header().Set("Retry-After", "7")
+		http.Error(w, "no such model", http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	_, err := NewOpenAIProvider(srv.URL, "", testRetry).Chat(context.Background(), NewRequest("m", "s", "u", Options{}))
+
+	var se *StatusError
+	if !errors.As(err, &se) {
+		t.Fatalf("err = %v, want *StatusError", err)
+	}
+	if se.Code != http.StatusNotFound || se.Body != "no such model" || se.RetryAfter != 7*time.Second {
+		t.Errorf("StatusError = %+v", se)
+	}
+	if !IsModelNotFound(err) {
+		t.Error("IsModelNotFound = false for 404")
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("404 was requested %d times, want no retries", n)
+	}
+}
+
+func TestPostJSONRetriesTooManyRequests(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits.Add(1) == 1 {
+			// Retry-After больше MaxDelay: пауза должна обрезаться до MaxDelay.
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"ok"}}]}`)
+	}))
+	defer srv.Close()
+
+	started := time.Now()
+	resp, err := NewOpenAIProvider(srv.URL, "", testRetry).Chat(context.Background(), NewRequest("m", "s", "u", Options{}))
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if resp.Content != "ok" {
+		t.Errorf("Content = %q, want %q", resp.Content, "ok")
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("requests = %d, want 2", n)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("retry waited %s, Retry-After was not clamped to MaxDelay", elapsed)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	p := RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	for attempt := 1; attempt <= 10; attempt++ {
+		limit := min(p.BaseDelay<<(attempt-1), p.MaxDelay)
+		for range 100 {
+			if d := p.delay(attempt, errors.New("boom")); d < 0 || d >= limit {
+				t.Fatalf("delay(%d) = %s, want [0, %s)", attempt, d, limit)
+			}
+		}
+	}
+
+	if d := p.delay(1, &StatusError{Code: http.StatusTooManyRequests, RetryAfter: time.Minute}); d != p.MaxDelay {
+		t.Errorf("delay with Retry-After 1m = %s, want %s", d, p.MaxDelay)
+	}
+	if d := p.delay(1, &StatusError{Code: http.StatusTooManyRequests, RetryAfter: 300 * time.Millisecond}); d != 300*time.Millisecond {
+		t.Errorf("delay with Retry-After 300ms = %s", d)
+	}
+}
@@ -0,0 +1,82 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// maxJSONAttempts — сколько раз модель может ответить невалидным JSON, прежде чем мы сдадимся.
+const maxJSONAttempts = 3
+
+// Validator is implemented by structured answers that can check their own schema.
+type Validator interface {
+	Validate() error
+}
+
+// ChatJSON asks the provider for a JSON object in JSON mode, decodes it into out and validates it.
+// When the answer is not valid JSON or violates the schema, the model is shown its answer and
+// the error and asked again, up to maxJSONAttempts times. It returns the raw accepted JSON with
+// the usage summed over all attempts.
+func ChatJSON(ctx context.Context, p Provider, req Request, out Validator) (Response, error) {
+	req.JSON = true
+	req.Messages = append([]ChatMessage(nil), req.Messages...)
+
+	var usage Usage
+	var lastErr error
+	for attempt := 1; attempt <= maxJSONAttempts; attempt++ {
+		resp, err := p.Chat(ctx, req)
+		usage = usage.Add(resp.Usage)
+		if err != nil {
+			return Response{Usage: usage}, err
+		}
+
+		raw := resp.Content
+		if lastErr = decodeAndValidate(raw, out); lastErr == nil {
+			return Response{Content: raw, Usage: usage}, nil
+		}
+
+		req.Messages = append(req.Messages,
+			ChatMessage{Role: "assistant", Content: raw},
+			ChatMessage{Role: "user", Content: fmt.Sprintf(
+				"Ответ не соответствует схеме: %v. Верни только исправленный JSON-объект без пояснений.", lastErr)},
+		)
+	}
+
+	return Response{Usage: usage}, fmt.Errorf("invalid JSON after %d attempts: %w", maxJSONAttempts, lastErr)
+}
+
+func decodeAndValidate(raw string, out Validator) error {
+	raw = strings.TrimSpace(raw)
+	// Некоторые модели даже в JSON-режиме оборачивают ответ в ```json ... ```.
+	raw = strings.TrimPrefix(raw, "```json")
+	raw = strings.TrimPrefix(raw, "```")
+	raw = strings.TrimSuffix(raw, "```")
+
+	// Сбрасываем результат предыдущей попытки, чтобы ее поля не «прошли» валидацию за новую.
+	if v := reflect.ValueOf(out); v.Kind() == reflect.Pointer && !v.IsNil() {
+		resetDecoded(v.Elem())
+	}
+
+	if err := json.Unmarshal([]byte(raw), out); err != nil {
+		return fmt.Errorf("decode json: %w", err)
+	}
+	return out.Validate()
+}
+
+// resetDecoded zeroes what json.Unmarshal can fill. Unexported fields of a struct are kept:
+// the decoder never writes them, so they hold validation settings such as the analyses
+// a comparison must rank.
+func resetDecoded(v reflect.Value) {
+	if v.Kind() != reflect.Struct {
+		v.SetZero()
+		return
+	}
+	for i := range v.NumField() {
+		if v.Type().Field(i).IsExported() {
+			v.Field(i).SetZero()
+		}
+	}
+}
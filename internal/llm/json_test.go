@@ -0,0 +1,89 @@
+package llm
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"BoardAI/internal/models"
+)
+
+// scriptedProvider answers Chat with the given replies in order and records the requests.
+type scriptedProvider struct {
+	replies  []string
+	requests []Request
+}
+
+func (p *scriptedProvider) Chat(ctx context.Context, req Request) (Response, error) {
+	p.requests = append(p.requests, req)
+	reply := p.replies[0]
+	p.replies = p.replies[1:]
+	return Response{Content: reply, Usage: Usage{Model: req.Model, PromptTokens: 10, CompletionTokens: 5}}, nil
+}
+
+func (p *scriptedProvider) ChatStream(ctx context.Context, req Request, onDelta DeltaFunc) (Response, error) {
+	return p.Chat(ctx, req)
+}
+
+const validRanking = `{"ranking":[
+	{"id":2,"reason":"быстрее окупается","finance":"окупаемость 8 месяцев"},
+	{"id":1,"reason":"рынок меньше","finance":"вложения 3 млн"}
+],"summary":"Вторая идея сильнее"}`
+
+func TestChatJSONComparison(t *testing.T) {
+	p := &scriptedProvider{replies: []string{validRanking}}
+	c := models.NewComparison([]int64{1, 2})
+
+	resp, err := ChatJSON(context.Background(), p, NewRequest("m", "s", "u", Options{}), c)
+	if err != nil {
+		t.Fatalf("ChatJSON: %v", err)
+	}
+	if len(p.requests) != 1 || !p.requests[0].JSON {
+		t.Errorf("requests = %+v, want one JSON-mode call", p.requests)
+	}
+	if len(c.Ranking) != 2 || c.Ranking[0].AnalysisID != 2 || c.Summary == "" {
+		t.Errorf("comparison = %+v", c)
+	}
+	if _, place, ok := c.Entry(1); !ok || place != 2 {
+		t.Errorf("Entry(1) = place %d, %v", place, ok)
+	}
+	if resp.Usage.PromptTokens != 10 {
+		t.Errorf("Usage = %+v", resp.Usage)
+	}
+}
+
+func TestChatJSONRetriesInvalidRanking(t *testing.T) {
+	p := &scriptedProvider{replies: []string{
+		`{"ranking":[],"summary":"нечего сравнивать"}`,
+		`{"ranking":[{"id":1,"reason":"единственная"}],"summary":"…"}`,
+		validRanking,
+	}}
+	c := models.NewComparison([]int64{1, 2})
+
+	resp, err := ChatJSON(context.Background(), p, NewRequest("m", "s", "u", Options{}), c)
+	if err != nil {
+		t.Fatalf("ChatJSON: %v", err)
+	}
+	if len(p.requests) != 3 {
+		t.Fatalf("calls = %d, want 3", len(p.requests))
+	}
+	last := p.requests[2].Messages
+	if fix := last[len(last)-1].Content; !strings.Contains(fix, "analysis 2 is missing") {
+		t.Errorf("the model was not told what was wrong: %q", fix)
+	}
+	if len(c.Ranking) != 2 {
+		t.Errorf("ranking = %+v", c.Ranking)
+	}
+	if resp.Usage.PromptTokens != 30 {
+		t.Errorf("usage of all attempts = %+v", resp.Usage)
+	}
+}
+
+func TestChatJSONGivesUp(t *testing.T) {
+	p := &scriptedProvider{replies: []string{"не JSON", `{"ranking":[]}`, "```json\n{\"ranking\":[{\"id\":3,\"reason\":\"x\"}]}\n```"}}
+
+	_, err := ChatJSON(context.Background(), p, NewRequest("m", "s", "u", Options{}), models.NewComparison([]int64{1, 2}))
+	if err == nil || !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Fatalf("err = %v, want invalid JSON after 3 attempts", err)
+	}
+}
@@ -0,0 +1,144 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OllamaProvider talks to the native Ollama /api/chat endpoint (base URL without /v1).
+type OllamaProvider struct {
+	httpBackend
+}
+
+// NewOllamaProvider creates the provider; a zero retry policy means DefaultRetryPolicy.
+func NewOllamaProvider(baseURL string, retry RetryPolicy) *OllamaProvider {
+	return &OllamaProvider{httpBackend: newHTTPBackend(baseURL, retry)}
+}
+
+type ollamaChatRequest struct {
+	Model    string        `json:"model"`
+	Messages []ChatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+	// Format "json" включает JSON-режим Ollama.
+	Format  string        `json:"format,omitempty"`
+	Options ollamaOptions `json:"options"`
+}
+
+type ollamaOptions struct {
+	Temperature float32 `json:"temperature"`
+	NumPredict  int     `json:"num_predict,omitempty"`
+}
+
+// ollamaChatResponse is both the non-streamed answer and one NDJSON line of a streamed one.
+// Счетчики токенов (prompt_eval_count, eval_count) есть только в последнем объекте с done=true.
+type ollamaChatResponse struct {
+	Message         ChatMessage `json:"message"`
+	Done            bool        `json:"done"`
+	Error           string      `json:"error"`
+	PromptEvalCount int         `json:"prompt_eval_count"`
+	EvalCount       int         `json:"eval_count"`
+}
+
+func (r *ollamaChatResponse) usage(model string, started time.Time) Usage {
+	return Usage{
+		Model:            model,
+		PromptTokens:     r.PromptEvalCount,
+		CompletionTokens: r.EvalCount,
+		Latency:          time.Since(started),
+	}
+}
+
+func (p *OllamaProvider) Chat(ctx context.Context, req Request) (Response, error) {
+	started := time.Now()
+	resp, err := p.postJSON(ctx, "/api/chat", nil, newOllamaChatRequest(req, false))
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	var parsed ollamaChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return Response{}, fmt.Errorf("decode response: %w", err)
+	}
+	usage := parsed.usage(req.Model, started)
+	if parsed.Error != "" {
+		return Response{Usage: usage}, fmt.Errorf("ollama error: %s", parsed.Error)
+	}
+	if parsed.Message.Content == "" {
+		return Response{Usage: usage}, fmt.Errorf("empty ollama response")
+	}
+
+	return Response{Content: parsed.Message.Content, Usage: usage}, nil
+}
+
+func (p *OllamaProvider) ChatStream(ctx context.Context, req Request, onDelta DeltaFunc) (Response, error) {
+	started := time.Now()
+	resp, err := p.postJSON(ctx, "/api/chat", nil, newOllamaChatRequest(req, true))
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	var last ollamaChatResponse
+	var sb strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	// Ollama стримит NDJSON: по одному JSON-объекту на строку, последний с done=true.
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var chunk ollamaChatResponse
+		if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+			return Response{Content: sb.String(), Usage: last.usage(req.Model, started)}, fmt.Errorf("decode stream chunk: %w", err)
+		}
+		if chunk.Error != "" {
+			return Response{Content: sb.String(), Usage: last.usage(req.Model, started)}, fmt.Errorf("ollama error: %s", chunk.Error)
+		}
+
+		if chunk.Message.Content != "" {
+			sb.WriteString(chunk.Message.Content)
+			if onDelta != nil {
+				onDelta(chunk.Message.Content)
+			}
+		}
+		if chunk.Done {
+			last = chunk
+			break
+		}
+	}
+
+	result := Response{Content: sb.String(), Usage: last.usage(req.Model, started)}
+	if err := scanner.Err(); err != nil {
+		return result, fmt.Errorf("read stream: %w", err)
+	}
+
+	if sb.Len() == 0 {
+		return result, fmt.Errorf("empty streamed ollama response")
+	}
+
+	return result, nil
+}
+
+func newOllamaChatRequest(r Request, stream bool) ollamaChatRequest {
+	req := ollamaChatRequest{
+		Model:    r.Model,
+		Messages: r.Messages,
+		Stream:   stream,
+		Options: ollamaOptions{
+			Temperature: r.Temperature,
+			NumPredict:  r.MaxTokens,
+		},
+	}
+	if r.JSON {
+		req.Format = "json"
+	}
+	return req
+}
@@ -0,0 +1,99 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOllamaChat(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		var req ollamaChatRequest
+		decodeBody(t, r, &req)
+		if req.Model != "llama" || req.Stream || req.Format != "json" || req.Options.NumPredict != 64 {
+			t.Errorf("request = %+v", req)
+		}
+
+		io.WriteString(w, `{"message":{"role":"assistant","content":"{}"},"done":true,"prompt_eval_count":20,"eval_count":4}`)
+	}))
+	defer srv.Close()
+
+	resp, err := NewOllamaProvider(srv.URL, testRetry).Chat(context.Background(), NewRequest("llama", "s", "u", Options{MaxTokens: 64, JSON: true}))
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if resp.Content != "{}" {
+		t.Errorf("Content = %q", resp.Content)
+	}
+	if resp.Usage.Model != "llama" || resp.Usage.PromptTokens != 20 || resp.Usage.CompletionTokens != 4 {
+		t.Errorf("Usage = %+v", resp.Usage)
+	}
+}
+
+func TestOllamaChatError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"error":"model is loading"}`)
+	}))
+	defer srv.Close()
+
+	_, err := NewOllamaProvider(srv.URL, testRetry).Chat(context.Background(), NewRequest("llama", "s", "u", Options{}))
+	if err == nil || !strings.Contains(err.Error(), "model is loading") {
+		t.Fatalf("err = %v, want the ollama error", err)
+	}
+}
+
+func TestOllamaChatStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ollamaChatRequest
+		decodeBody(t, r, &req)
+		if !req.Stream || req.Format != "" {
+			t.Errorf("stream request = %+v", req)
+		}
+
+		io.WriteString(w, strings.Join([]string{
+			`{"message":{"role":"assistant","content":"Да"},"done":false}`,
+			``,
+			`{"message":{"role":"assistant","content":", конечно"},"done":false}`,
+			`{"message":{"role":"assistant","content":""},"done":true,"prompt_eval_count":9,"eval_count":3}`,
+			``,
+		}, "\n"))
+	}))
+	defer srv.Close()
+
+	var deltas []string
+	resp, err := NewOllamaProvider(srv.URL, testRetry).ChatStream(context.Background(),
+		NewRequest("llama", "s", "u", Options{}), func(d string) { deltas = append(deltas, d) })
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	if resp.Content != "Да, конечно" {
+		t.Errorf("Content = %q", resp.Content)
+	}
+	if len(deltas) != 2 {
+		t.Errorf("deltas = %q", deltas)
+	}
+	if resp.Usage.PromptTokens != 9 || resp.Usage.CompletionTokens != 3 {
+		t.Errorf("Usage = %+v", resp.Usage)
+	}
+}
+
+func TestOllamaChatStreamError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "{\"message\":{\"content\":\"Нач\"},\"done\":false}\n{\"error\":\"out of memory\"}\n")
+	}))
+	defer srv.Close()
+
+	resp, err := NewOllamaProvider(srv.URL, testRetry).ChatStream(context.Background(), NewRequest("llama", "s", "u", Options{}), nil)
+	if err == nil || !strings.Contains(err.Error(), "out of memory") {
+		t.Fatalf("err = %v, want the ollama error", err)
+	}
+	if resp.Content != "Нач" {
+		t.Errorf("partial Content = %q", resp.Content)
+	}
+}
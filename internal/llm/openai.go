@@ -0,0 +1,181 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OpenAIProvider talks to an OpenAI-compatible /chat/completions endpoint (OpenAI, Ollama /v1, vLLM...).
+type OpenAIProvider struct {
+	httpBackend
+	apiKey string
+}
+
+// NewOpenAIProvider creates the provider; a zero retry policy means DefaultRetryPolicy.
+func NewOpenAIProvider(baseURL, apiKey string, retry RetryPolicy) *OpenAIProvider {
+	return &OpenAIProvider{
+		httpBackend: newHTTPBackend(baseURL, retry),
+		apiKey:      apiKey,
+	}
+}
+
+type ChatCompletionRequest struct {
+	Model       string        `json:"model"`
+	Messages    []ChatMessage `json:"messages"`
+	Stream      bool          `json:"stream"`
+	MaxTokens   int           `json:"max_tokens,omitempty"`
+	Temperature float32       `json:"temperature"`
+	// ResponseFormat включает JSON-режим: {"type":"json_object"}.
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	// StreamOptions просит прислать usage последним событием стрима.
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+}
+
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// CompletionUsage is the usage block of /chat/completions; servers that do not count tokens omit it.
+type CompletionUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+}
+
+type ResponseFormat struct {
+	Type string `json:"type"`
+}
+
+type ChatCompletionResponse struct {
+	Choices []struct {
+		Message ChatMessage `json:"message"`
+	} `json:"choices"`
+	Usage *CompletionUsage `json:"usage"`
+}
+
+// ChatCompletionChunk is a single SSE event of a streamed /chat/completions response.
+type ChatCompletionChunk struct {
+	Choices []struct {
+		Delta        ChatMessage `json:"delta"`
+		FinishReason *string     `json:"finish_reason"`
+	} `json:"choices"`
+	// Usage приходит в последнем событии (с пустым choices), если запрошен include_usage.
+	Usage *CompletionUsage `json:"usage"`
+}
+
+func (u *CompletionUsage) toUsage(model string, started time.Time) Usage {
+	usage := Usage{Model: model, Latency: time.Since(started)}
+	if u != nil {
+		usage.PromptTokens = u.PromptTokens
+		usage.CompletionTokens = u.CompletionTokens
+	}
+	return usage
+}
+
+func (p *OpenAIProvider) Chat(ctx context.Context, req Request) (Response, error) {
+	started := time.Now()
+	resp, err := p.postJSON(ctx, "/chat/completions", p.headers(false), newChatCompletionRequest(req, false))
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	var parsed ChatCompletionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return Response{}, fmt.Errorf("decode response: %w", err)
+	}
+	usage := parsed.Usage.toUsage(req.Model, started)
+
+	if len(parsed.Choices) == 0 {
+		return Response{Usage: usage}, fmt.Errorf("empty choices in LLM response")
+	}
+
+	return Response{Content: parsed.Choices[0].Message.Content, Usage: usage}, nil
+}
+
+func (p *OpenAIProvider) ChatStream(ctx context.Context, req Request, onDelta DeltaFunc) (Response, error) {
+	started := time.Now()
+	resp, err := p.postJSON(ctx, "/chat/completions", p.headers(true), newChatCompletionRequest(req, true))
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	var usage *CompletionUsage
+	var sb strings.Builder
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			// Пустые строки-разделители, комментарии ": ping" и т.п.
+			continue
+		}
+
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk ChatCompletionChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return Response{Content: sb.String(), Usage: usage.toUsage(req.Model, started)}, fmt.Errorf("decode stream chunk: %w", err)
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage
+		}
+
+		for _, ch := range chunk.Choices {
+			if ch.Delta.Content == "" {
+				continue
+			}
+			sb.WriteString(ch.Delta.Content)
+			if onDelta != nil {
+				onDelta(ch.Delta.Content)
+			}
+		}
+	}
+
+	result := Response{Content: sb.String(), Usage: usage.toUsage(req.Model, started)}
+	if err := scanner.Err(); err != nil {
+		return result, fmt.Errorf("read stream: %w", err)
+	}
+
+	if sb.Len() == 0 {
+		return result, fmt.Errorf("empty streamed LLM response")
+	}
+
+	return result, nil
+}
+
+func (p *OpenAIProvider) headers(stream bool) map[string]string {
+	h := map[string]string{}
+	if stream {
+		h["Accept"] = "text/event-stream"
+	}
+	if p.apiKey != "" {
+		h["Authorization"] = "Bearer " + p.apiKey
+	}
+	return h
+}
+
+func newChatCompletionRequest(r Request, stream bool) ChatCompletionRequest {
+	req := ChatCompletionRequest{
+		Model:       r.Model,
+		Messages:    r.Messages,
+		Stream:      stream,
+		Temperature: r.Temperature,
+		MaxTokens:   r.MaxTokens,
+	}
+	if r.JSON {
+		req.ResponseFormat = &ResponseFormat{Type: "json_object"}
+	}
+	if stream {
+		req.StreamOptions = &StreamOptions{IncludeUsage: true}
+	}
+	return req
+}
@@ -0,0 +1,120 @@
+package llm
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenAIChat(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			t.Errorf("Authorization = %q", got)
+		}
+
+		var req ChatCompletionRequest
+		decodeBody(t, r, &req)
+		if req.Model != "gpt" || req.Stream || req.MaxTokens != 50 || len(req.Messages) != 2 {
+			t.Errorf("request = %+v", req)
+		}
+		if req.ResponseFormat == nil || req.ResponseFormat.Type != "json_object" {
+			t.Errorf("response_format = %+v, want json_object", req.ResponseFormat)
+		}
+
+		io.WriteString(w, `{"choices":[{"message":{"role":"assistant","content":"{\"a\":1}"}}],"usage":{"prompt_tokens":12,"completion_tokens":5}}`)
+	}))
+	defer srv.Close()
+
+	p := NewOpenAIProvider(srv.URL+"/", "secret", testRetry)
+	resp, err := p.Chat(context.Background(), NewRequest("gpt", "sys", "user", Options{MaxTokens: 50, JSON: true}))
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if resp.Content != `{"a":1}` {
+		t.Errorf("Content = %q", resp.Content)
+	}
+	if resp.Usage.Model != "gpt" || resp.Usage.PromptTokens != 12 || resp.Usage.CompletionTokens != 5 {
+		t.Errorf("Usage = %+v", resp.Usage)
+	}
+}
+
+func TestOpenAIChatEmptyChoices(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"choices":[],"usage":{"prompt_tokens":3,"completion_tokens":0}}`)
+	}))
+	defer srv.Close()
+
+	resp, err := NewOpenAIProvider(srv.URL, "", testRetry).Chat(context.Background(), NewRequest("gpt", "s", "u", Options{}))
+	if err == nil {
+		t.Fatal("Chat: want error for empty choices")
+	}
+	if resp.Usage.PromptTokens != 3 {
+		t.Errorf("usage of a failed call is lost: %+v", resp.Usage)
+	}
+}
+
+func TestOpenAIChatStream(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Accept"); got != "text/event-stream" {
+			t.Errorf("Accept = %q", got)
+		}
+		var req ChatCompletionRequest
+		decodeBody(t, r, &req)
+		if !req.Stream || req.StreamOptions == nil || !req.StreamOptions.IncludeUsage {
+			t.Errorf("stream request = %+v", req)
+		}
+		if req.ResponseFormat != nil {
+			t.Errorf("response_format = %+v for a plain request", req.ResponseFormat)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, strings.Join([]string{
+			`: ping`,
+			`data: {"choices":[{"delta":{"role":"assistant","content":""}}]}`,
+			``,
+			`data: {"choices":[{"delta":{"content":"При"}}]}`,
+			``,
+			`data: {"choices":[{"delta":{"content":"вет"},"finish_reason":"stop"}]}`,
+			``,
+			`data: {"choices":[],"usage":{"prompt_tokens":7,"completion_tokens":2}}`,
+			``,
+			`data: [DONE]`,
+			``,
+		}, "\n"))
+	}))
+	defer srv.Close()
+
+	var deltas []string
+	resp, err := NewOpenAIProvider(srv.URL, "", testRetry).ChatStream(context.Background(),
+		NewRequest("gpt", "s", "u", Options{}), func(d string) { deltas = append(deltas, d) })
+	if err != nil {
+		t.Fatalf("ChatStream: %v", err)
+	}
+	if resp.Content != "Привет" {
+		t.Errorf("Content = %q", resp.Content)
+	}
+	if strings.Join(deltas, "|") != "При|вет" {
+		t.Errorf("deltas = %q", deltas)
+	}
+	if resp.Usage.PromptTokens != 7 || resp.Usage.CompletionTokens != 2 {
+		t.Errorf("Usage = %+v", resp.Usage)
+	}
+}
+
+func TestOpenAIChatStreamEmpty(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "data: {\"choices\":[{\"delta\":{\"content\":\"\"}}]}\n\ndata: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	_, err := NewOpenAIProvider(srv.URL, "", testRetry).ChatStream(context.Background(), NewRequest("gpt", "s", "u", Options{}), nil)
+	if err == nil || !strings.Contains(err.Error(), "empty streamed") {
+		t.Fatalf("err = %v, want empty streamed response", err)
+	}
+}
@@ -0,0 +1,93 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Provider is an LLM backend able to run a chat conversation. Both methods report the token
+// usage of the call when the API returns it, and always its latency.
+type Provider interface {
+	Chat(ctx context.Context, req Request) (Response, error)
+	// ChatStream calls onDelta for every piece of text and returns the full answer at the end.
+	ChatStream(ctx context.Context, req Request, onDelta DeltaFunc) (Response, error)
+}
+
+// Request is a provider-independent chat request. Messages may include a leading "system" message.
+type Request struct {
+	Model    string
+	Messages []ChatMessage
+	Options
+}
+
+type ChatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Options tunes sampling for a single request.
+type Options struct {
+	Temperature float32
+	MaxTokens   int
+	// JSON asks the model to answer with a single JSON object.
+	JSON bool
+}
+
+// DeltaFunc receives each piece of text as it arrives from the model.
+type DeltaFunc func(delta string)
+
+// NewRequest builds the usual system + user request.
+func NewRequest(model, systemPrompt, userPrompt string, opts Options) Request {
+	return Request{
+		Model: model,
+		Messages: []ChatMessage{
+			{Role: "system", Content: systemPrompt},
+			{Role: "user", Content: userPrompt},
+		},
+		Options: opts,
+	}
+}
+
+// splitSystem separates system messages (joined) from the rest of the conversation,
+// for APIs that take the system prompt as a separate field.
+func splitSystem(messages []ChatMessage) (string, []ChatMessage) {
+	var system []string
+	var rest []ChatMessage
+	for _, m := range messages {
+		if m.Role == "system" {
+			system = append(system, m.Content)
+			continue
+		}
+		rest = append(rest, m)
+	}
+	return strings.Join(system, "\n\n"), rest
+}
+
+// Provider names used in the board definition.
+const (
+	ProviderOpenAI    = "openai"
+	ProviderOllama    = "ollama"
+	ProviderAnthropic = "anthropic"
+)
+
+// Registry maps provider names from the board definition to configured backends.
+type Registry map[string]Provider
+
+// Get returns the named provider; an empty name means ProviderOpenAI.
+func (r Registry) Get(name string) (Provider, error) {
+	if name == "" {
+		name = ProviderOpenAI
+	}
+	p, ok := r[name]
+	if !ok {
+		known := make([]string, 0, len(r))
+		for k := range r {
+			known = append(known, k)
+		}
+		sort.Strings(known)
+		return nil, fmt.Errorf("llm provider %q is not configured (available: %s)", name, strings.Join(known, ", "))
+	}
+	return p, nil
+}
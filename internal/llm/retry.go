@@ -0,0 +1,125 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy controls how failed HTTP calls to the model endpoint are retried.
+type RetryPolicy struct {
+	// MaxRetries — число повторов после первой попытки; 0 отключает повторы.
+	MaxRetries int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+}
+
+// DefaultRetryPolicy is used when the client is created with a zero policy.
+var DefaultRetryPolicy = RetryPolicy{MaxRetries: 3, BaseDelay: time.Second, MaxDelay: 30 * time.Second}
+
+// StatusError is returned when the endpoint answers with a non-2xx status.
+type StatusError struct {
+	Code   int
+	Status string
+	Body   string
+	// RetryAfter is parsed from the Retry-After header, zero when absent.
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	if e.Body == "" {
+		return fmt.Sprintf("llm http status: %s", e.Status)
+	}
+	return fmt.Sprintf("llm http status: %s: %s", e.Status, e.Body)
+}
+
+// IsModelNotFound reports whether err means the requested model does not exist on the endpoint.
+func IsModelNotFound(err error) bool {
+	var se *StatusError
+	return errors.As(err, &se) && se.Code == http.StatusNotFound
+}
+
+// retryable reports whether the request may succeed if repeated: 429, 5xx, timeouts and dropped connections.
+func retryable(err error) bool {
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.Code == http.StatusTooManyRequests || se.Code >= 500
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.EOF)
+}
+
+// delay returns the pause before retry number attempt (starting from 1): exponential backoff with
+// full jitter, a random pause in [0, min(BaseDelay·2^(attempt-1), MaxDelay)), or the server's
+// Retry-After when it sent one.
+func (p RetryPolicy) delay(attempt int, err error) time.Duration {
+	var se *StatusError
+	if errors.As(err, &se) && se.RetryAfter > 0 {
+		if p.MaxDelay > 0 && se.RetryAfter > p.MaxDelay {
+			return p.MaxDelay
+		}
+		return se.RetryAfter
+	}
+
+	backoff := p.BaseDelay << (attempt - 1)
+	if backoff <= 0 || (p.MaxDelay > 0 && backoff > p.MaxDelay) {
+		backoff = p.MaxDelay
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(backoff)))
+}
+
+// withRetry calls fn until it succeeds, fails with a non-retryable error, runs out of retries
+// or ctx is done.
+func (p RetryPolicy) withRetry(ctx context.Context, fn func() (*http.Response, error)) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := fn()
+		if err == nil {
+			return resp, nil
+		}
+		if ctx.Err() != nil || attempt >= p.MaxRetries || !retryable(err) {
+			return nil, err
+		}
+
+		wait := p.delay(attempt+1, err)
+		log.Printf("llm request failed (%v), retry %d/%d in %s", err, attempt+1, p.MaxRetries, wait)
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(wait):
+		}
+	}
+}
+
+func parseRetryAfter(h string) time.Duration {
+	if h == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(h); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(h); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
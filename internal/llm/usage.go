@@ -0,0 +1,116 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Usage is what one or more model calls consumed: tokens as reported by the API and the time
+// spent waiting for the answers. Model is the model of the last call.
+type Usage struct {
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	Latency          time.Duration
+}
+
+// Add sums u and other; the model of other wins when it is set.
+func (u Usage) Add(other Usage) Usage {
+	if other.Model != "" {
+		u.Model = other.Model
+	}
+	u.PromptTokens += other.PromptTokens
+	u.CompletionTokens += other.CompletionTokens
+	u.Latency += other.Latency
+	return u
+}
+
+// Response is the answer of a chat call together with its usage.
+type Response struct {
+	Content string
+	Usage   Usage
+}
+
+// Price is the cost of a model in USD per million tokens.
+type Price struct {
+	Prompt     float64 `yaml:"prompt"`
+	Completion float64 `yaml:"completion"`
+}
+
+// PriceTable maps model names to prices. Models missing from the table (local Ollama models)
+// cost nothing.
+type PriceTable map[string]Price
+
+// LoadPrices reads a YAML price table: model name → {prompt, completion} in USD per 1M tokens.
+func LoadPrices(path string) (PriceTable, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read price table: %w", err)
+	}
+	var t PriceTable
+	if err := yaml.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("parse price table %s: %w", path, err)
+	}
+	for model, p := range t {
+		if p.Prompt < 0 || p.Completion < 0 {
+			return nil, fmt.Errorf("price table %s: negative price for %q", path, model)
+		}
+	}
+	return t, nil
+}
+
+// Cost returns the price of u in USD.
+func (t PriceTable) Cost(u Usage) float64 {
+	p, ok := t[u.Model]
+	if !ok {
+		return 0
+	}
+	return (float64(u.PromptTokens)*p.Prompt + float64(u.CompletionTokens)*p.Completion) / 1e6
+}
+
+// Meter sums the usage and cost of the calls made with a context it is attached to.
+// Cost is computed per call, so a meter stays correct when an agent falls back to another model.
+type Meter struct {
+	prices PriceTable
+
+	mu    sync.Mutex
+	usage Usage
+	cost  float64
+}
+
+func NewMeter(prices PriceTable) *Meter {
+	return &Meter{prices: prices}
+}
+
+func (m *Meter) add(u Usage) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.usage = m.usage.Add(u)
+	m.cost += m.prices.Cost(u)
+}
+
+// Total returns the accumulated usage and its cost in USD.
+func (m *Meter) Total() (Usage, float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.usage, m.cost
+}
+
+type meterKey struct{}
+
+// WithMeter attaches m to ctx; every call recorded with RecordUsage under ctx goes to m.
+func WithMeter(ctx context.Context, m *Meter) context.Context {
+	return context.WithValue(ctx, meterKey{}, m)
+}
+
+// RecordUsage adds u to the meter attached to ctx, if there is one.
+func RecordUsage(ctx context.Context, u Usage) {
+	if m, ok := ctx.Value(meterKey{}).(*Meter); ok {
+		m.add(u)
+	}
+}
@@ -3,14 +3,124 @@ package models
 import "time"
 
 type Analysis struct {
-	ID         int64  `db:"id" json:"id"`
-	UserID     int64  `db:"user_id" json:"user_id"`
-	IdeaText   string `db:"idea_text" json:"idea_text"`
-	Strategist string `db:"strategist" json:"strategist"`
-	Financier  string `db:"financier" json:"financier"`
-	Auditor    string `db:"auditor" json:"auditor"`
-	Analyst    string `db:"analyst" json:"analyst"`
-	Moderator  string `db:"moderator" json:"moderator"`
+	ID       int64  `db:"id" json:"id"`
+	UserID   int64  `db:"user_id" json:"user_id"`
+	IdeaText string `db:"idea_text" json:"idea_text"`
+	// Brief — бриф, составленный по ответам автора на уточняющие вопросы; пустой, если интервью не было.
+	Brief string `db:"brief" json:"brief,omitempty"`
+	// Attachments — документы, приложенные к идее, уже в виде текста и таблиц.
+	Attachments []Attachment `db:"attachments" json:"attachments,omitempty"`
+	// Reports — отчеты экспертов в порядке, заданном описанием совета.
+	Reports   []Report `db:"reports" json:"reports"`
+	Moderator string   `db:"moderator" json:"moderator"`
+	// Verdict — разобранный JSON-вердикт модератора; nil, если модель так и не вернула валидный JSON.
+	Verdict *Verdict `db:"-" json:"verdict,omitempty"`
+
+	// Rounds хранит стенограмму дебатов: первый раунд — исходные отчеты, дальше — опровержения.
+	Rounds []DebateRound `db:"debate" json:"rounds,omitempty"`
+
+	// Status и время запуска ведутся воркером: анализ пишется в БД с момента старта.
+	Status     AnalysisStatus `db:"status" json:"status"`
+	Error      string         `db:"error" json:"error,omitempty"`
+	StartedAt  *time.Time     `db:"started_at" json:"started_at,omitempty"`
+	FinishedAt *time.Time     `db:"finished_at" json:"finished_at,omitempty"`
+	// AgentRuns — сколько работал каждый агент на каждом этапе, в порядке завершения.
+	AgentRuns []AgentRun `db:"agent_runs" json:"agent_runs,omitempty"`
+	// Токены и стоимость всех вызовов LLM за анализ (сумма по AgentRuns).
+	PromptTokens     int     `db:"prompt_tokens" json:"prompt_tokens"`
+	CompletionTokens int     `db:"completion_tokens" json:"completion_tokens"`
+	CostUSD          float64 `db:"cost_usd" json:"cost_usd"`
+	// Pinned — анализ отмечен пользователем как избранный.
+	Pinned bool `db:"pinned" json:"pinned"`
 
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
 }
+
+// Duration returns how long the run took, or zero while it is unfinished.
+func (a *Analysis) Duration() time.Duration {
+	if a.StartedAt == nil || a.FinishedAt == nil {
+		return 0
+	}
+	return a.FinishedAt.Sub(*a.StartedAt)
+}
+
+// AddUsage adds the tokens and cost of runs to the analysis totals.
+func (a *Analysis) AddUsage(runs ...AgentRun) {
+	for _, r := range runs {
+		a.PromptTokens += r.PromptTokens
+		a.CompletionTokens += r.CompletionTokens
+		a.CostUSD += r.CostUSD
+	}
+}
+
+type AnalysisStatus string
+
+const (
+	AnalysisRunning   AnalysisStatus = "running"
+	AnalysisCompleted AnalysisStatus = "completed"
+	AnalysisFailed    AnalysisStatus = "failed"
+	AnalysisCancelled AnalysisStatus = "cancelled"
+)
+
+// Stages of an analysis an agent run belongs to.
+const (
+	StageReport  = "report"
+	StageDebate  = "debate"
+	StageVerdict = "verdict"
+	// StageSummary — сжатие отчета эксперта для модератора.
+	StageSummary = "summary"
+	// StageFollowUp — ответ эксперта на вопрос пользователя по готовому анализу.
+	StageFollowUp = "followup"
+	// StageInterview — уточняющие вопросы автору идеи и бриф по его ответам, до заседания совета.
+	StageInterview = "interview"
+	// StageCompare — сравнение модератором нескольких готовых анализов.
+	StageCompare = "compare"
+	// StageRerun — повторный прогон одного эксперта или вердикта по готовому анализу.
+	StageRerun = "rerun"
+)
+
+// AgentRun is one agent call within an analysis; Round is set for debate rounds only.
+// Tokens and cost cover every LLM request of the call, including JSON re-asks and fallbacks;
+// LatencyMs is the time spent waiting for the model, DurationMs the whole call.
+type AgentRun struct {
+	Role             string    `json:"role"`
+	Stage            string    `json:"stage"`
+	Round            int       `json:"round,omitempty"`
+	Model            string    `json:"model,omitempty"`
+	StartedAt        time.Time `json:"started_at"`
+	DurationMs       int64     `json:"duration_ms"`
+	LatencyMs        int64     `json:"latency_ms,omitempty"`
+	PromptTokens     int       `json:"prompt_tokens,omitempty"`
+	CompletionTokens int       `json:"completion_tokens,omitempty"`
+	CostUSD          float64   `json:"cost_usd,omitempty"`
+	Error            string    `json:"error,omitempty"`
+}
+
+// Duration returns how long the agent ran.
+func (r AgentRun) Duration() time.Duration {
+	return time.Duration(r.DurationMs) * time.Millisecond
+}
+
+// Report is one expert's final answer; Role is the board role id.
+type Report struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+	// Assessment заполняется только для ролей с structured: true.
+	Assessment *Assessment `json:"assessment,omitempty"`
+}
+
+// Report returns the content of the given role's report and whether it exists.
+func (a *Analysis) Report(role string) (string, bool) {
+	for _, r := range a.Reports {
+		if r.Role == role {
+			return r.Content, true
+		}
+	}
+	return "", false
+}
+
+// DebateRound holds what every expert said in one round of the board debate.
+type DebateRound struct {
+	Round   int      `json:"round"`
+	Entries []Report `json:"entries"`
+}
@@ -0,0 +1,27 @@
+package models
+
+// Kinds of attached documents.
+const (
+	AttachmentPDF  = "pdf"
+	AttachmentDOCX = "docx"
+	AttachmentXLSX = "xlsx"
+	AttachmentCSV  = "csv"
+	AttachmentText = "text"
+)
+
+// Attachment is a document the user sent along with the idea, already converted to text.
+// Spreadsheets keep their cells in Tables so the numbers reach the experts that need them intact.
+type Attachment struct {
+	Name   string  `json:"name"`
+	Kind   string  `json:"kind"`
+	Text   string  `json:"text,omitempty"`
+	Tables []Table `json:"tables,omitempty"`
+	// Truncated — документ был длиннее лимита и сохранен не полностью.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Table is one sheet of a spreadsheet or one table of a document.
+type Table struct {
+	Name string     `json:"name"`
+	Rows [][]string `json:"rows"`
+}
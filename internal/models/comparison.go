@@ -0,0 +1,60 @@
+package models
+
+import "fmt"
+
+// Comparison is the moderator's ranking of several analyses of competing ideas, best first.
+type Comparison struct {
+	Ranking []ComparisonEntry `json:"ranking"`
+	Summary string            `json:"summary"`
+
+	// expected — id сравниваемых анализов: каждый должен попасть в рейтинг ровно один раз.
+	expected []int64
+}
+
+// ComparisonEntry is one place of the ranking.
+type ComparisonEntry struct {
+	AnalysisID int64  `json:"id"`
+	Reason     string `json:"reason"`
+	// Finance — ключевые финансовые допущения идеи: вложения, окупаемость, маржа.
+	Finance string `json:"finance"`
+}
+
+// NewComparison returns an empty comparison that validates against the given analyses.
+func NewComparison(ids []int64) *Comparison {
+	return &Comparison{expected: ids}
+}
+
+func (c *Comparison) Validate() error {
+	if len(c.Ranking) == 0 {
+		return fmt.Errorf("ranking is empty")
+	}
+	seen := make(map[int64]bool, len(c.Ranking))
+	for _, e := range c.Ranking {
+		if seen[e.AnalysisID] {
+			return fmt.Errorf("analysis %d is ranked twice", e.AnalysisID)
+		}
+		seen[e.AnalysisID] = true
+		if e.Reason == "" {
+			return fmt.Errorf("ranking: reason is required for analysis %d", e.AnalysisID)
+		}
+	}
+	for _, id := range c.expected {
+		if !seen[id] {
+			return fmt.Errorf("analysis %d is missing from the ranking", id)
+		}
+	}
+	if len(c.Ranking) != len(c.expected) {
+		return fmt.Errorf("ranking must contain exactly the analyses %v", c.expected)
+	}
+	return nil
+}
+
+// Entry returns the ranking entry of the analysis and its place, starting from 1.
+func (c *Comparison) Entry(analysisID int64) (ComparisonEntry, int, bool) {
+	for i, e := range c.Ranking {
+		if e.AnalysisID == analysisID {
+			return e, i + 1, true
+		}
+	}
+	return ComparisonEntry{}, 0, false
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Authors of follow-up messages.
+const (
+	FollowUpFromUser   = "user"
+	FollowUpFromExpert = "expert"
+)
+
+// FollowUpMessage is one message of a follow-up conversation between the user and one expert
+// about a finished analysis.
+type FollowUpMessage struct {
+	ID         int64     `db:"id" json:"id"`
+	AnalysisID int64     `db:"analysis_id" json:"analysis_id"`
+	UserID     int64     `db:"user_id" json:"user_id"`
+	Role       string    `db:"role" json:"role"`
+	Author     string    `db:"author" json:"author"`
+	Content    string    `db:"content" json:"content"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// InterviewQuestions is the interviewer's answer: what to ask the author before the board convenes.
+type InterviewQuestions struct {
+	Questions []string `json:"questions"`
+}
+
+func (q *InterviewQuestions) Validate() error {
+	for _, s := range q.Questions {
+		if strings.TrimSpace(s) != "" {
+			return nil
+		}
+	}
+	return fmt.Errorf("at least one question is required")
+}
+
+// ClarifyingAnswer is one question of the intake interview with the author's answer.
+// Skipped отмечает вопрос, на который автор решил не отвечать.
+type ClarifyingAnswer struct {
+	Question string `json:"question"`
+	Answer   string `json:"answer,omitempty"`
+	Skipped  bool   `json:"skipped,omitempty"`
+}
+
+// Pending reports whether the question still waits for the author.
+func (c ClarifyingAnswer) Pending() bool {
+	return c.Answer == "" && !c.Skipped
+}
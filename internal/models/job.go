@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+type JobStatus string
+
+const (
+	JobQueued  JobStatus = "queued"
+	JobRunning JobStatus = "running"
+	JobDone    JobStatus = "done"
+	JobFailed  JobStatus = "failed"
+	// JobCancelled — задачу отменил пользователь, пока она ждала в очереди или выполнялась.
+	JobCancelled JobStatus = "cancelled"
+)
+
+// JobSource tells which frontend queued the job; only that frontend's workers pick it up.
+type JobSource string
+
+const (
+	JobSourceTelegram JobSource = "telegram"
+	JobSourceAPI      JobSource = "api"
+)
+
+// Job is a queued analysis request. The result is kept on the job as well as in the analysis it is linked to.
+type Job struct {
+	ID        int64     `db:"id" json:"id"`
+	Source    JobSource `db:"source" json:"source"`
+	UserID    int64     `db:"user_id" json:"user_id"`
+	ChatID    int64     `db:"chat_id" json:"chat_id"`
+	MessageID int       `db:"message_id" json:"message_id"`
+	// CallbackURL получает POST с результатом задачи из HTTP API.
+	CallbackURL string `db:"callback_url" json:"callback_url,omitempty"`
+	IdeaText    string `db:"idea_text" json:"idea_text"`
+	// Brief — бриф после уточняющего интервью; эксперты получают его вместе с идеей.
+	Brief string `db:"brief" json:"brief,omitempty"`
+	// Attachments передаются экспертам вместе с идеей.
+	Attachments []Attachment `db:"attachments" json:"attachments,omitempty"`
+	Status      JobStatus    `db:"status" json:"status"`
+	Attempts    int          `db:"attempts" json:"attempts"`
+	MaxAttempts int          `db:"max_attempts" json:"max_attempts"`
+	LastError   string       `db:"last_error" json:"last_error,omitempty"`
+	Result      *Analysis    `db:"result" json:"result,omitempty"`
+	// AnalysisID — id анализа в истории; заводится, когда воркер берет задачу.
+	AnalysisID int64 `db:"analysis_id" json:"analysis_id,omitempty"`
+
+	CreatedAt  time.Time  `db:"created_at" json:"created_at"`
+	FinishedAt *time.Time `db:"finished_at" json:"finished_at,omitempty"`
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Kinds of revisions.
+const (
+	RevisionReport  = "report"
+	RevisionVerdict = "verdict"
+)
+
+// Revision is a version of an expert report or of the verdict that a rerun has replaced.
+// The analysis itself always shows the latest version.
+type Revision struct {
+	ID         int64  `db:"id" json:"id"`
+	AnalysisID int64  `db:"analysis_id" json:"analysis_id"`
+	Kind       string `db:"kind" json:"kind"`
+	Role       string `db:"role" json:"role"`
+	// Model — модель, написавшая эту версию; пустая, если в истории вызовов ее не нашлось.
+	Model      string      `db:"model" json:"model,omitempty"`
+	Content    string      `db:"content" json:"content"`
+	Assessment *Assessment `db:"assessment" json:"assessment,omitempty"`
+	Verdict    *Verdict    `db:"verdict" json:"verdict,omitempty"`
+	// CreatedAt — когда версия была заменена.
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// ReplaceReport puts report in place of the report of the same role and returns the replaced
+// version, or nil if the role had no report.
+func (a *Analysis) ReplaceReport(report Report) *Revision {
+	for i, r := range a.Reports {
+		if r.Role != report.Role {
+			continue
+		}
+		rev := &Revision{
+			AnalysisID: a.ID,
+			Kind:       RevisionReport,
+			Role:       r.Role,
+			Model:      a.lastModel(r.Role, StageReport, StageDebate, StageRerun),
+			Content:    r.Content,
+			Assessment: r.Assessment,
+		}
+		a.Reports[i] = report
+		return rev
+	}
+	return nil
+}
+
+// ReplaceVerdict stores a new verdict of the moderator role and returns the replaced one.
+func (a *Analysis) ReplaceVerdict(role, moderator string, v *Verdict) *Revision {
+	rev := &Revision{
+		AnalysisID: a.ID,
+		Kind:       RevisionVerdict,
+		Role:       role,
+		Model:      a.lastModel(role, StageVerdict, StageRerun),
+		Content:    a.Moderator,
+		Verdict:    a.Verdict,
+	}
+	a.Moderator = moderator
+	a.Verdict = v
+	return rev
+}
+
+// lastModel returns the model of the last successful run of role at one of the stages.
+func (a *Analysis) lastModel(role string, stages ...string) string {
+	for i := len(a.AgentRuns) - 1; i >= 0; i-- {
+		r := a.AgentRuns[i]
+		if r.Role == role && r.Error == "" && r.Model != "" && slices.Contains(stages, r.Stage) {
+			return r.Model
+		}
+	}
+	return ""
+}
@@ -0,0 +1,20 @@
+package models
+
+import "fmt"
+
+// ReportSummary is a condensed expert report the moderator gets instead of the full text.
+type ReportSummary struct {
+	Stance    string   `json:"stance"`
+	KeyPoints []string `json:"key_points"`
+	Figures   []string `json:"figures"`
+}
+
+func (s *ReportSummary) Validate() error {
+	if s.Stance == "" {
+		return fmt.Errorf("stance is required")
+	}
+	if len(s.KeyPoints) == 0 {
+		return fmt.Errorf("at least one key point is required")
+	}
+	return nil
+}
@@ -0,0 +1,18 @@
+package models
+
+// UsageSummary is aggregated LLM usage; depending on the report it covers one user, one model
+// or everything. Analyses counts distinct analyses, Calls agent calls.
+type UsageSummary struct {
+	UserID           int64   `json:"user_id,omitempty"`
+	Model            string  `json:"model,omitempty"`
+	Analyses         int     `json:"analyses"`
+	Calls            int     `json:"calls"`
+	PromptTokens     int64   `json:"prompt_tokens"`
+	CompletionTokens int64   `json:"completion_tokens"`
+	CostUSD          float64 `json:"cost_usd"`
+}
+
+// TotalTokens returns prompt plus completion tokens.
+func (s *UsageSummary) TotalTokens() int64 {
+	return s.PromptTokens + s.CompletionTokens
+}
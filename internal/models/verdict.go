@@ -0,0 +1,104 @@
+package models
+
+import "fmt"
+
+// Decision is the moderator's final call on an idea.
+type Decision string
+
+const (
+	DecisionGo    Decision = "go"
+	DecisionNoGo  Decision = "no-go"
+	DecisionPivot Decision = "pivot"
+)
+
+var decisionTitles = map[Decision]string{
+	DecisionGo:    "Запускаем",
+	DecisionNoGo:  "Не запускаем",
+	DecisionPivot: "Пивот",
+}
+
+// Title returns the decision as it is shown to users.
+func (d Decision) Title() string {
+	if t, ok := decisionTitles[d]; ok {
+		return t
+	}
+	return string(d)
+}
+
+// VerdictDimensions are the axes the moderator scores every idea on.
+var VerdictDimensions = []string{"market", "finance", "risk", "execution", "innovation"}
+
+var dimensionTitles = map[string]string{
+	"market":     "рынок",
+	"finance":    "финансы",
+	"risk":       "риски",
+	"execution":  "реализация",
+	"innovation": "инновационность",
+}
+
+// DimensionTitle returns the user-facing name of a verdict dimension.
+func DimensionTitle(d string) string {
+	if t, ok := dimensionTitles[d]; ok {
+		return t
+	}
+	return d
+}
+
+// Verdict is the structured moderator answer. It is stored both inside the moderator JSONB
+// and in dedicated columns so that decisions and scores can be queried.
+type Verdict struct {
+	Decision        Decision       `json:"decision"`
+	Score           int            `json:"score"`
+	Summary         string         `json:"summary"`
+	Risks           []string       `json:"risks"`
+	Recommendations []string       `json:"recommendations"`
+	Dimensions      map[string]int `json:"dimensions"`
+}
+
+func (v *Verdict) Validate() error {
+	switch v.Decision {
+	case DecisionGo, DecisionNoGo, DecisionPivot:
+	default:
+		return fmt.Errorf("decision must be one of go, no-go, pivot, got %q", v.Decision)
+	}
+	if v.Score < 0 || v.Score > 100 {
+		return fmt.Errorf("score must be within 0..100, got %d", v.Score)
+	}
+	if v.Summary == "" {
+		return fmt.Errorf("summary is required")
+	}
+	if len(v.Risks) == 0 {
+		return fmt.Errorf("risks must contain at least one item")
+	}
+	if len(v.Recommendations) == 0 {
+		return fmt.Errorf("recommendations must contain at least one item")
+	}
+	for _, d := range VerdictDimensions {
+		score, ok := v.Dimensions[d]
+		if !ok {
+			return fmt.Errorf("dimensions.%s is required", d)
+		}
+		if score < 0 || score > 100 {
+			return fmt.Errorf("dimensions.%s must be within 0..100, got %d", d, score)
+		}
+	}
+	return nil
+}
+
+// Assessment is an optional structured summary produced by an expert.
+type Assessment struct {
+	Summary         string   `json:"summary"`
+	Score           int      `json:"score"`
+	Risks           []string `json:"risks"`
+	Recommendations []string `json:"recommendations"`
+}
+
+func (a *Assessment) Validate() error {
+	if a.Summary == "" {
+		return fmt.Errorf("summary is required")
+	}
+	if a.Score < 0 || a.Score > 100 {
+		return fmt.Errorf("score must be within 0..100, got %d", a.Score)
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package orchestrator
+
+import (
+	"BoardAI/internal/agents"
+	"BoardAI/internal/llm"
+	"BoardAI/internal/models"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Compare asks the moderator to rank finished analyses of competing ideas and to name the
+// financial assumptions of each. Every analysis gets an equal share of the moderator's context
+// window: its verdict in full and, in what is left, the reports of the roles with tables: true.
+// The call is reported to done.
+func (o *Orchestrator) Compare(ctx context.Context, analyses []*models.Analysis, done AgentDoneFunc) (*models.Comparison, error) {
+	if len(analyses) < 2 {
+		return nil, fmt.Errorf("at least two analyses are required, got %d", len(analyses))
+	}
+	m := o.board.Moderator()
+	ag, ok := o.agents[agents.Role(m.ID)]
+	if !ok {
+		return nil, fmt.Errorf("moderator agent not initialized")
+	}
+
+	budget := (m.ContextWindow - m.MaxTokens - promptReserve - estimateTokens(m.Prompt) -
+		estimateTokens(llm.CompareJSONInstruction)) / len(analyses)
+	budget = max(budget, minReportBudget)
+
+	ids := make([]int64, len(analyses))
+	var sb strings.Builder
+	for i, a := range analyses {
+		ids[i] = a.ID
+		sb.WriteString(o.comparisonInput(a, budget))
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString(llm.CompareJSONInstruction)
+
+	t := newTracker(o.prices(), done)
+	callCtx, call := t.start(ctx, m.ID, models.StageCompare, 0)
+	c := models.NewComparison(ids)
+	_, err := ag.RunJSON(callCtx, sb.String(), c)
+	t.finish(call, err)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// comparisonInput describes one analysis for the comparison within budget tokens.
+func (o *Orchestrator) comparisonInput(a *models.Analysis, budget int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "━━━ АНАЛИЗ #%d ━━━\n💡 ИДЕЯ: %s\n", a.ID, truncateTokens(ideaWithBrief(a.IdeaText, a.Brief), budget/4))
+
+	if v := a.Verdict; v != nil {
+		fmt.Fprintf(&sb, "ВЕРДИКТ: %s, оценка %d/100\n%s\n", v.Decision.Title(), v.Score, v.Summary)
+		for _, d := range models.VerdictDimensions {
+			if s, ok := v.Dimensions[d]; ok {
+				fmt.Fprintf(&sb, "%s: %d/100\n", models.DimensionTitle(d), s)
+			}
+		}
+		sb.WriteString("Риски:\n")
+		writeList(&sb, v.Risks)
+	} else {
+		fmt.Fprintf(&sb, "ВЕРДИКТ:\n%s\n", truncateTokens(a.Moderator, budget/4))
+	}
+
+	// Финансовые допущения берем из отчетов ролей с таблицами: это финансисты совета.
+	left := budget - estimateTokens(sb.String())
+	for _, r := range o.board.Experts() {
+		report, ok := a.Report(r.ID)
+		if !r.Tables || !ok || left < minReportBudget {
+			continue
+		}
+		text := fmt.Sprintf("\n%s:\n%s\n", r.Name, truncateTokens(report, left))
+		sb.WriteString(text)
+		left -= estimateTokens(text)
+	}
+	return strings.TrimSpace(sb.String())
+}
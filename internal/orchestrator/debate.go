@@ -0,0 +1,70 @@
+package orchestrator
+
+import (
+	"BoardAI/internal/agents"
+	"BoardAI/internal/board"
+	"BoardAI/internal/llm"
+	"BoardAI/internal/models"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// runDebateRound shows every expert the latest reports of the others and asks for a rebuttal
+// and a revised report. An expert whose rebuttal fails keeps its previous report.
+func (o *Orchestrator) runDebateRound(ctx context.Context, t *tracker, round int, idea string, prev map[agents.Role]models.Report, progress ProgressFunc) map[agents.Role]models.Report {
+	revised := o.runExperts(ctx, t, models.StageDebate, round, func(r board.Role) string {
+		return o.buildRebuttalPrompt(idea, r, prev)
+	}, progress)
+
+	next := make(map[agents.Role]models.Report, len(prev))
+	for role, report := range prev {
+		next[role] = report
+	}
+	for role, report := range revised {
+		next[role] = report
+	}
+	return next
+}
+
+// rebuttalInstruction closes every debate prompt.
+const rebuttalInstruction = "Это раунд дебатов совета директоров. Укажи, с какими утверждениями коллег ты не согласен и почему, " +
+	"признай обоснованные возражения и дай исправленную версию своего отчета."
+
+// buildRebuttalPrompt shows the expert its previous report and those of the others within its
+// context window: its own report gets at most half of the free space, the others share the rest
+// equally and are truncated to their share.
+func (o *Orchestrator) buildRebuttalPrompt(idea string, self board.Role, reports map[agents.Role]models.Report) string {
+	free := self.ContextWindow - self.MaxTokens - promptReserve - estimateTokens(self.Prompt) -
+		estimateTokens(idea) - estimateTokens(rebuttalInstruction)
+	if self.Structured {
+		free -= estimateTokens(llm.AssessmentJSONInstruction)
+	}
+	own := truncateTokens(reports[agents.Role(self.ID)].Content, max(free/2, minReportBudget))
+	free -= estimateTokens(own)
+
+	var others []board.Role
+	for _, r := range o.board.Experts() {
+		if _, ok := reports[agents.Role(r.ID)]; ok && r.ID != self.ID {
+			others = append(others, r)
+		}
+	}
+	share := minReportBudget
+	if len(others) > 0 {
+		share = max(free/len(others), minReportBudget)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "💡 ИДЕЯ: %s\n\n", idea)
+	fmt.Fprintf(&sb, "📝 ТВОЙ ПРЕДЫДУЩИЙ ОТЧЕТ:\n%s\n\n", own)
+	sb.WriteString("🗣 ОТЧЕТЫ ДРУГИХ ЭКСПЕРТОВ:\n")
+	for _, r := range others {
+		fmt.Fprintf(&sb, "🔹 %s: %s\n\n", r.Name, truncateTokens(reports[agents.Role(r.ID)].Content, share))
+	}
+	sb.WriteString(rebuttalInstruction)
+	return sb.String()
+}
+
+func (o *Orchestrator) newDebateRound(n int, reports map[agents.Role]models.Report) models.DebateRound {
+	return models.DebateRound{Round: n, Entries: o.collectReports(reports)}
+}
@@ -0,0 +1,159 @@
+package orchestrator
+
+import (
+	"BoardAI/internal/board"
+	"BoardAI/internal/document"
+	"BoardAI/internal/llm"
+	"BoardAI/internal/models"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// docChunkTokens — размер фрагмента документа; мелкие фрагменты точнее отбираются под роль.
+	docChunkTokens = 400
+	// stemRunes — грубая основа слова: для русского языка первых пяти букв обычно хватает.
+	stemRunes = 5
+)
+
+// Input is what the board analyses: the idea, the brief from the clarification interview and
+// the documents attached to it.
+type Input struct {
+	Idea        string
+	Brief       string
+	Attachments []models.Attachment
+}
+
+// text returns the idea together with the brief.
+func (in Input) text() string {
+	return ideaWithBrief(in.Idea, in.Brief)
+}
+
+// docChunk is a piece of an attached document.
+type docChunk struct {
+	doc   int
+	seq   int
+	text  string
+	score int
+}
+
+// expertInput builds the prompt of an expert: the idea and the brief followed by the parts of the attached
+// documents that fit the role's context window. Roles with tables: true get the spreadsheets
+// in full first; the rest of the budget goes to the chunks that share the most words with the
+// role's prompt and the idea.
+func expertInput(r board.Role, in Input) string {
+	idea := in.text()
+	if len(in.Attachments) == 0 {
+		return idea
+	}
+
+	budget := r.ContextWindow - r.MaxTokens - promptReserve - estimateTokens(r.Prompt) - estimateTokens(idea)
+	if r.Structured {
+		budget -= estimateTokens(llm.AssessmentJSONInstruction)
+	}
+	budget = max(budget, minReportBudget)
+
+	var tables []string
+	var chunks []docChunk
+	for i, a := range in.Attachments {
+		for _, t := range a.Tables {
+			text := document.RenderTable(t)
+			if r.Tables {
+				tables = append(tables, fmt.Sprintf("--- %s ---\n%s", a.Name, text))
+				continue
+			}
+			for _, c := range splitChunks(text, docChunkTokens*runesPerToken) {
+				chunks = append(chunks, docChunk{doc: i, seq: len(chunks), text: c})
+			}
+		}
+		for _, c := range splitChunks(a.Text, docChunkTokens*runesPerToken) {
+			chunks = append(chunks, docChunk{doc: i, seq: len(chunks), text: c})
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(idea)
+	sb.WriteString("\n\nПРИЛОЖЕННЫЕ ДОКУМЕНТЫ")
+
+	// Таблицы идут первыми и могут занять весь бюджет: для финансиста цифры важнее текста.
+	var tablesText string
+	if len(tables) > 0 {
+		tablesText = truncateTokens(strings.Join(tables, "\n\n"), budget)
+		budget -= estimateTokens(tablesText)
+	}
+
+	selected, partial := selectChunks(chunks, keywords(r.Prompt+" "+idea), budget)
+	if partial || strings.HasSuffix(tablesText, "[текст сокращен]") {
+		sb.WriteString(" (приведены фрагменты, относящиеся к твоей области)")
+	}
+	sb.WriteString(":\n")
+	if tablesText != "" {
+		sb.WriteString("\n" + tablesText + "\n")
+	}
+
+	doc := -1
+	for _, c := range selected {
+		if c.doc != doc {
+			doc = c.doc
+			fmt.Fprintf(&sb, "\n--- %s ---\n", in.Attachments[doc].Name)
+		} else {
+			sb.WriteString("\n[...]\n")
+		}
+		sb.WriteString(c.text + "\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// selectChunks picks the best-scoring chunks that fit into budget tokens and returns them in
+// document order; it reports whether some chunks were left out.
+func selectChunks(chunks []docChunk, kw map[string]bool, budget int) ([]docChunk, bool) {
+	total := 0
+	for _, c := range chunks {
+		total += estimateTokens(c.text)
+	}
+	if total <= budget {
+		return chunks, false
+	}
+
+	ranked := make([]docChunk, len(chunks))
+	for i, c := range chunks {
+		c.score = 0
+		for stem := range keywords(c.text) {
+			if kw[stem] {
+				c.score++
+			}
+		}
+		ranked[i] = c
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+
+	var selected []docChunk
+	for _, c := range ranked {
+		if cost := estimateTokens(c.text); cost <= budget {
+			selected = append(selected, c)
+			budget -= cost
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].seq < selected[j].seq })
+	return selected, true
+}
+
+// keywords returns the stems of the words of text that are long enough to mean something.
+func keywords(text string) map[string]bool {
+	kw := make(map[string]bool)
+	for _, w := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	}) {
+		rs := []rune(w)
+		if len(rs) < 4 {
+			continue
+		}
+		if len(rs) > stemRunes {
+			rs = rs[:stemRunes]
+		}
+		kw[string(rs)] = true
+	}
+	return kw
+}
@@ -0,0 +1,71 @@
+package orchestrator
+
+import (
+	"BoardAI/internal/agents"
+	"BoardAI/internal/llm"
+	"BoardAI/internal/models"
+	"context"
+	"fmt"
+)
+
+// FollowUp answers the user's question to one expert about a finished analysis. The expert sees
+// the idea with its brief, its own report and the earlier messages of the conversation; messages
+// that do not fit the role's context window are dropped from the oldest end. The call is reported to done.
+func (o *Orchestrator) FollowUp(ctx context.Context, a *models.Analysis, role string, history []*models.FollowUpMessage, question string, done AgentDoneFunc) (string, error) {
+	ag, ok := o.agents[agents.Role(role)]
+	if !ok {
+		return "", fmt.Errorf("unknown role %q", role)
+	}
+	r, _ := o.board.Role(role)
+	report, ok := a.Report(role)
+	if !ok {
+		return "", fmt.Errorf("analysis %d has no report of %q", a.ID, role)
+	}
+
+	idea := ideaWithBrief(a.IdeaText, a.Brief)
+	free := r.ContextWindow - r.MaxTokens - promptReserve - estimateTokens(r.Prompt) -
+		estimateTokens(llm.FollowUpInstruction) - estimateTokens(idea) - estimateTokens(question)
+	// Отчет важнее старых реплик: отдаем ему не больше половины свободного места.
+	report = truncateTokens(report, max(free/2, minReportBudget))
+	free -= estimateTokens(report)
+
+	grounding := fmt.Sprintf("%s\n\nИДЕЯ:\n%s\n\nТВОЙ ОТЧЕТ:\n%s", llm.FollowUpInstruction, idea, report)
+	messages := []llm.ChatMessage{{Role: "system", Content: grounding}}
+	messages = append(messages, fitHistory(history, free)...)
+	messages = append(messages, llm.ChatMessage{Role: "user", Content: question})
+
+	t := newTracker(o.prices(), done)
+	callCtx, call := t.start(ctx, role, models.StageFollowUp, 0)
+	answer, err := ag.Chat(callCtx, messages)
+	t.finish(call, err)
+	if err != nil {
+		return "", err
+	}
+	return answer, nil
+}
+
+// fitHistory converts the newest messages that fit into budget tokens into chat messages, oldest
+// first. The conversation is cut at a question, so it never starts with an orphaned answer.
+func fitHistory(history []*models.FollowUpMessage, budget int) []llm.ChatMessage {
+	start := len(history)
+	for i := len(history) - 1; i >= 0; i-- {
+		budget -= estimateTokens(history[i].Content)
+		if budget < 0 {
+			break
+		}
+		start = i
+	}
+	for start < len(history) && history[start].Author != models.FollowUpFromUser {
+		start++
+	}
+
+	messages := make([]llm.ChatMessage, 0, len(history)-start)
+	for _, m := range history[start:] {
+		role := "user"
+		if m.Author == models.FollowUpFromExpert {
+			role = "assistant"
+		}
+		messages = append(messages, llm.ChatMessage{Role: role, Content: m.Content})
+	}
+	return messages
+}
@@ -0,0 +1,100 @@
+package orchestrator
+
+import (
+	"BoardAI/internal/agents"
+	"BoardAI/internal/board"
+	"BoardAI/internal/llm"
+	"BoardAI/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+const (
+	// interviewerRole — под этим id вызовы интервьюера попадают в учет токенов.
+	interviewerRole = "interviewer"
+	// maxInterviewQuestions — больше вопросов пользователь обычно не дочитывает.
+	maxInterviewQuestions = 5
+)
+
+// newInterviewer builds the agent that asks the author clarifying questions and writes the brief.
+// Like the summarizer it runs on the moderator's provider and models.
+func newInterviewer(providers llm.Registry, moderator board.Role) (agents.Agent, error) {
+	temp := float32(0.2)
+	r := moderator
+	r.ID = interviewerRole
+	r.Prompt = llm.SystemPromptInterviewer
+	r.Temperature = &temp
+	return agents.NewAgent(providers, r)
+}
+
+// Interview returns up to maxInterviewQuestions questions that fill the gaps of a short idea.
+// The call is reported to done.
+func (o *Orchestrator) Interview(ctx context.Context, idea string, done AgentDoneFunc) ([]string, error) {
+	prompt := fmt.Sprintf("ИДЕЯ:\n%s\n\n%s", idea, llm.InterviewJSONInstruction)
+
+	t := newTracker(o.prices(), done)
+	callCtx, call := t.start(ctx, interviewerRole, models.StageInterview, 0)
+	var q models.InterviewQuestions
+	_, err := o.interviewer.RunJSON(callCtx, prompt, &q)
+	t.finish(call, err)
+	if err != nil {
+		return nil, err
+	}
+
+	var questions []string
+	for _, s := range q.Questions {
+		if s = strings.TrimSpace(s); s != "" && len(questions) < maxInterviewQuestions {
+			questions = append(questions, s)
+		}
+	}
+	return questions, nil
+}
+
+// ComposeBrief turns the idea and the author's answers into a brief for the board. If the model
+// fails or runs out of time, the answers themselves become the brief: they are still better than nothing.
+// The call is reported to done.
+func (o *Orchestrator) ComposeBrief(ctx context.Context, idea string, answers []models.ClarifyingAnswer, done AgentDoneFunc) (string, error) {
+	qa := renderAnswers(answers)
+	prompt := fmt.Sprintf("ИДЕЯ:\n%s\n\nОТВЕТЫ АВТОРА:\n%s\n\n%s", idea, qa, llm.BriefInstruction)
+
+	t := newTracker(o.prices(), done)
+	callCtx, call := t.start(ctx, interviewerRole, models.StageInterview, 0)
+	brief, err := o.interviewer.Run(callCtx, prompt)
+	t.finish(call, err)
+	if err != nil {
+		// Отмена — это остановка бота; по таймауту, как и при ошибке модели, обходимся ответами.
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return "", err
+		}
+		log.Printf("compose brief error: %v", err)
+		return qa, nil
+	}
+	if brief = strings.TrimSpace(brief); brief == "" {
+		return qa, nil
+	}
+	return brief, nil
+}
+
+// renderAnswers lists the questions with the author's answers; skipped questions are marked as unknown.
+func renderAnswers(answers []models.ClarifyingAnswer) string {
+	var sb strings.Builder
+	for _, a := range answers {
+		answer := a.Answer
+		if answer == "" {
+			answer = "неизвестно"
+		}
+		fmt.Fprintf(&sb, "%s\n%s\n\n", a.Question, answer)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// ideaWithBrief is the idea as the board sees it: the author's text followed by the brief.
+func ideaWithBrief(idea, brief string) string {
+	if brief == "" {
+		return idea
+	}
+	return idea + "\n\nБРИФ ПО ОТВЕТАМ АВТОРА:\n" + brief
+}
@@ -2,120 +2,245 @@ package orchestrator
 
 import (
 	"BoardAI/internal/agents"
+	"BoardAI/internal/board"
 	"BoardAI/internal/config"
 	"BoardAI/internal/llm"
 	"BoardAI/internal/models"
 	"context"
 	"fmt"
+	"log"
+	"strings"
+	"sync"
 	"time"
 )
 
+// ProgressFunc receives the text an agent has produced so far while it is still streaming.
+// It is called with the accumulated answer, not with individual deltas.
+type ProgressFunc func(role agents.Role, text string)
+
 // Orchestrator runs multiple agents and aggregates results.
 type Orchestrator struct {
-	agents map[agents.Role]agents.Agent
-	cfg    *config.Config
+	agents      map[agents.Role]agents.Agent
+	summarizer  agents.Agent
+	interviewer agents.Agent
+	board       *board.Board
+	cfg         *config.Config
+	// providers нужны, чтобы перезапустить роль на другой модели.
+	providers llm.Registry
 }
 
-// NewOrchestrator constructs orchestrator with all agents from LLM client.
-func NewOrchestrator(cli *llm.Client, cfg *config.Config) *Orchestrator {
+// NewOrchestrator constructs orchestrator with all agents of the configured board.
+// It fails if a role refers to a provider that is not in the registry.
+func NewOrchestrator(providers llm.Registry, cfg *config.Config) (*Orchestrator, error) {
+	ags, err := agents.NewAgentsFromBoard(providers, cfg.Board)
+	if err != nil {
+		return nil, err
+	}
+	summarizer, err := newSummarizer(providers, cfg.Board.Moderator())
+	if err != nil {
+		return nil, err
+	}
+	interviewer, err := newInterviewer(providers, cfg.Board.Moderator())
+	if err != nil {
+		return nil, err
+	}
 	return &Orchestrator{
-		agents: agents.NewAgentsFromConfig(cli, cfg),
-		cfg:    cfg,
+		agents:      ags,
+		summarizer:  summarizer,
+		interviewer: interviewer,
+		board:       cfg.Board,
+		cfg:         cfg,
+		providers:   providers,
+	}, nil
+}
+
+// Board returns the board definition the orchestrator works with.
+func (o *Orchestrator) Board() *board.Board {
+	return o.board
+}
+
+// runAgent runs a single agent, streaming its output into progress when it is set.
+func runAgent(ctx context.Context, ag agents.Agent, input string, progress ProgressFunc) (string, error) {
+	if progress == nil {
+		return ag.Run(ctx, input)
 	}
+
+	return ag.RunStream(ctx, input, func(text string) {
+		progress(ag.Role(), text)
+	})
 }
 
-// limitText обрезает слишком длинные ответы экспертов, чтобы не переполнять контекст Ollama.
-func limitText(s string, maxChars int) string {
-	if len(s) <= maxChars {
-		return s
+// runExperts runs the board experts through a worker pool of cfg.MaxParallelAgents workers.
+// With a limit of 1 the experts run sequentially, which is what CPU-only installs want.
+// input builds the prompt for every role; roles that fail are missing from the result.
+// Every call is recorded in t under stage and round.
+func (o *Orchestrator) runExperts(ctx context.Context, t *tracker, stage string, round int, input func(board.Role) string, progress ProgressFunc) map[agents.Role]models.Report {
+	experts := o.board.Experts()
+
+	workers := 1
+	if o.cfg != nil && o.cfg.MaxParallelAgents > 1 {
+		workers = o.cfg.MaxParallelAgents
+	}
+	if workers > len(experts) {
+		workers = len(experts)
+	}
+
+	tasks := make(chan board.Role)
+	results := make(map[agents.Role]models.Report, len(experts))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range tasks {
+				ag, ok := o.agents[agents.Role(r.ID)]
+				if !ok {
+					continue
+				}
+
+				callCtx, call := t.start(ctx, r.ID, stage, round)
+				out, err := runExpert(callCtx, r, ag, input(r), progress)
+				t.finish(call, err)
+				if err != nil {
+					log.Printf("%s error: %v", r.ID, err)
+					continue
+				}
+
+				mu.Lock()
+				results[agents.Role(r.ID)] = out
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, r := range experts {
+		tasks <- r
 	}
-	return s[:maxChars] + "... [текст сокращен]"
+	close(tasks)
+	wg.Wait()
+
+	return results
 }
 
-// RunAnalysis runs expert agents sequentially to save CPU resources.
-func (o *Orchestrator) RunAnalysis(parentCtx context.Context, idea string, userID int64) (*models.Analysis, error) {
-	if o.agents == nil {
+// RunAnalysis runs expert agents with at most cfg.MaxParallelAgents in flight, optionally lets them
+// debate for cfg.DebateRounds rounds, then asks the moderator for the verdict. The brief, if any,
+// follows the idea everywhere; each expert also gets the parts of the attached documents that fit
+// its context window.
+// progress may be nil; otherwise it is notified as each agent's answer streams in and must be
+// safe for concurrent use when parallelism is above 1. agentDone may be nil too; it receives the
+// timing of every agent call as soon as it finishes, including the calls of a run that fails later.
+func (o *Orchestrator) RunAnalysis(parentCtx context.Context, in Input, userID int64, progress ProgressFunc, agentDone AgentDoneFunc) (*models.Analysis, error) {
+	if o.agents == nil || o.board == nil {
 		return nil, fmt.Errorf("agents not initialized")
 	}
+	idea := in.text()
+	startedAt := time.Now()
+	t := newTracker(o.prices(), agentDone)
 
 	// Увеличиваем таймаут до 15 минут, так как 5 агентов на CPU — это долго
 	ctx, cancel := context.WithTimeout(parentCtx, 15*time.Minute)
 	defer cancel()
 
-	var strategist, financier, auditor, analyst string
-	var err error
-
-	// 1. Стратег
-	if ag, ok := o.agents[agents.RoleStrategist]; ok {
-		strategist, err = ag.Run(ctx, idea)
-		if err != nil {
-			fmt.Printf("Strategist error: %v\n", err)
-			strategist = "Ошибка анализа стратега"
+	reports := o.runExperts(ctx, t, models.StageReport, 0, func(r board.Role) string { return expertInput(r, in) }, progress)
+	// Отмененный анализ не доводим до дебатов и вердикта: все следующие вызовы LLM все равно упадут.
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	for _, r := range o.board.Experts() {
+		if _, ok := reports[agents.Role(r.ID)]; !ok {
+			reports[agents.Role(r.ID)] = models.Report{
+				Role:    r.ID,
+				Content: fmt.Sprintf("Ошибка: %s не смог подготовить отчет", r.Name),
+			}
 		}
 	}
 
-	// 2. Финансист
-	if ag, ok := o.agents[agents.RoleFinancier]; ok {
-		financier, err = ag.Run(ctx, idea)
-		if err != nil {
-			fmt.Printf("Financier error: %v\n", err)
-			financier = "Ошибка финансового анализа"
+	// Без дебатов стенограмма совпадала бы с самими отчетами, поэтому сохраняем ее только при DebateRounds > 0.
+	var rounds []models.DebateRound
+	if o.cfg != nil && o.cfg.DebateRounds > 0 {
+		rounds = append(rounds, o.newDebateRound(1, reports))
+		for i := 0; i < o.cfg.DebateRounds; i++ {
+			reports = o.runDebateRound(ctx, t, i+2, idea, reports, progress)
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			rounds = append(rounds, o.newDebateRound(i+2, reports))
 		}
 	}
 
-	// 3. Аудитор
-	if ag, ok := o.agents[agents.RoleAuditor]; ok {
-		auditor, err = ag.Run(ctx, idea)
-		if err != nil {
-			fmt.Printf("Auditor error: %v\n", err)
-			auditor = "Ошибка аудита"
-		}
+	// Финальный вердикт
+	moderator, verdict, err := o.decide(ctx, t, models.StageVerdict, idea, reports, progress)
+	if err != nil {
+		return nil, err
 	}
 
-	// 4. Аналитик
-	if ag, ok := o.agents[agents.RoleAnalyst]; ok {
-		analyst, err = ag.Run(ctx, idea)
-		if err != nil {
-			fmt.Printf("Analyst error: %v\n", err)
-			analyst = "Ошибка анализа рынка"
-		}
+	finishedAt := time.Now()
+	a := &models.Analysis{
+		UserID:      userID,
+		IdeaText:    in.Idea,
+		Brief:       in.Brief,
+		Attachments: in.Attachments,
+		Reports:     o.collectReports(reports),
+		Moderator:   moderator,
+		Verdict:     verdict,
+		Rounds:      rounds,
+		Status:      models.AnalysisCompleted,
+		StartedAt:   &startedAt,
+		FinishedAt:  &finishedAt,
+		AgentRuns:   t.result(),
 	}
+	a.AddUsage(a.AgentRuns...)
+	return a, nil
+}
 
-	// 5. Модератор (Финальный вердикт)
-	moderatorAgent := o.agents[agents.RoleModerator]
+// decide asks the moderator for the verdict on the reports; the call is recorded in t under stage.
+func (o *Orchestrator) decide(ctx context.Context, t *tracker, stage, idea string, reports map[agents.Role]models.Report, progress ProgressFunc) (string, *models.Verdict, error) {
+	moderatorRole := o.board.Moderator()
+	moderatorAgent := o.agents[agents.Role(moderatorRole.ID)]
 	if moderatorAgent == nil {
-		return nil, fmt.Errorf("moderator agent not initialized")
-	}
-
-	moderatorPrompt := fmt.Sprintf(
-		"🏁 ФИНАЛЬНЫЙ ВЕРДИКТ\n\n"+
-			"%s\n\n"+
-			"--------------------------\n"+
-			"💡 ИДЕЯ: %s\n\n"+
-			"📋 КРАТКИЕ ОТЧЕТЫ ЭКСПЕРТОВ:\n"+
-			"🔹 Стратег: %s\n"+
-			"🔹 Финансист: %s\n"+
-			"🔹 *Аудитор:* %s\n"+
-			"🔹 *Аналитик:* %s",
-		idea,
-		limitText(strategist, 200),
-		limitText(financier, 200),
-		limitText(auditor, 200),
-		limitText(analyst, 200),
-	)
-
-	moderator, err := moderatorAgent.Run(ctx, moderatorPrompt)
+		return "", nil, fmt.Errorf("moderator agent not initialized")
+	}
+
+	// Отчеты целиком могут не влезть в контекст модератора, поэтому длинные сжимаются до тезисов.
+	summaries := o.summarizeReports(ctx, t, idea, reports)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "🏁 ФИНАЛЬНЫЙ ВЕРДИКТ\n\n--------------------------\n💡 ИДЕЯ: %s\n\n📋 ОТЧЕТЫ ЭКСПЕРТОВ:\n", idea)
+	for _, r := range o.board.Experts() {
+		if !r.ReportsToModerator() {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n🔹 %s:\n%s\n", r.Name, summaries[agents.Role(r.ID)])
+	}
+
+	callCtx, call := t.start(ctx, moderatorRole.ID, stage, 0)
+	moderator, verdict, err := o.runModerator(callCtx, moderatorAgent, sb.String(), progress)
+	t.finish(call, err)
 	if err != nil {
-		return nil, fmt.Errorf("moderator run error: %w", err)
+		return "", nil, fmt.Errorf("moderator run error: %w", err)
 	}
+	return moderator, verdict, nil
+}
 
-	return &models.Analysis{
-		UserID:     userID,
-		IdeaText:   idea,
-		Strategist: strategist,
-		Financier:  financier,
-		Auditor:    auditor,
-		Analyst:    analyst,
-		Moderator:  moderator,
-	}, nil
+// prices returns the configured price table; without one every model is free.
+func (o *Orchestrator) prices() llm.PriceTable {
+	if o.cfg == nil {
+		return nil
+	}
+	return o.cfg.Prices
+}
+
+// collectReports orders reports the way the board lists its experts.
+func (o *Orchestrator) collectReports(reports map[agents.Role]models.Report) []models.Report {
+	var result []models.Report
+	for _, r := range o.board.Experts() {
+		report, ok := reports[agents.Role(r.ID)]
+		if !ok {
+			continue
+		}
+		result = append(result, report)
+	}
+	return result
 }
@@ -0,0 +1,24 @@
+package orchestrator
+
+import (
+	"BoardAI/internal/config"
+	"BoardAI/internal/llm"
+)
+
+// NewProviders builds the LLM backends that board roles can select with the provider field.
+func NewProviders(cfg *config.Config) llm.Registry {
+	retry := llm.RetryPolicy{
+		MaxRetries: cfg.LLMMaxRetries,
+		BaseDelay:  cfg.LLMRetryBaseDelay,
+		MaxDelay:   cfg.LLMRetryMaxDelay,
+	}
+
+	providers := llm.Registry{
+		llm.ProviderOpenAI: llm.NewOpenAIProvider(cfg.OllamaBaseURL, cfg.OllamaAPIToken, retry),
+		llm.ProviderOllama: llm.NewOllamaProvider(cfg.OllamaNativeURL, retry),
+	}
+	if cfg.AnthropicAPIKey != "" {
+		providers[llm.ProviderAnthropic] = llm.NewAnthropicProvider(cfg.AnthropicBaseURL, cfg.AnthropicAPIKey, retry)
+	}
+	return providers
+}
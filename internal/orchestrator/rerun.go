@@ -0,0 +1,104 @@
+package orchestrator
+
+import (
+	"BoardAI/internal/agents"
+	"BoardAI/internal/board"
+	"BoardAI/internal/models"
+	"context"
+	"fmt"
+	"slices"
+)
+
+// maxRerunModels — больше вариантов модели в одном меню не помещается.
+const maxRerunModels = 6
+
+// RerunModels returns the models role can be rerun with: its own model first, then its fallbacks
+// and the models of the other roles on the same provider.
+func (o *Orchestrator) RerunModels(role string) []string {
+	r, ok := o.board.Role(role)
+	if !ok {
+		return nil
+	}
+	result := r.Models()
+	for _, other := range o.board.Roles {
+		if other.Provider != r.Provider {
+			continue
+		}
+		for _, m := range other.Models() {
+			if !slices.Contains(result, m) {
+				result = append(result, m)
+			}
+		}
+	}
+	return result[:min(len(result), maxRerunModels)]
+}
+
+// RerunExpert runs one expert of a finished analysis again on the idea, the brief and the attached
+// documents and puts the new report in place of the old one. An empty model or the role's own
+// model keeps the role's fallbacks; any other model is tried alone. a is updated in place, the new
+// call is added to its agent runs, and the replaced report is returned as a revision.
+// The call is reported to done.
+func (o *Orchestrator) RerunExpert(ctx context.Context, a *models.Analysis, role, model string, done AgentDoneFunc) (*models.Revision, error) {
+	r, ok := o.board.Role(role)
+	if !ok || r.Moderator {
+		return nil, fmt.Errorf("unknown expert %q", role)
+	}
+	if _, ok := a.Report(role); !ok {
+		return nil, fmt.Errorf("analysis %d has no report of %q", a.ID, role)
+	}
+	ag, err := o.agentWithModel(r, model)
+	if err != nil {
+		return nil, err
+	}
+
+	t := newTracker(o.prices(), done)
+	in := Input{Idea: a.IdeaText, Brief: a.Brief, Attachments: a.Attachments}
+	callCtx, call := t.start(ctx, role, models.StageRerun, 0)
+	report, err := runExpert(callCtx, r, ag, expertInput(r, in), nil)
+	t.finish(call, err)
+	if err != nil {
+		return nil, err
+	}
+
+	rev := a.ReplaceReport(report)
+	runs := t.result()
+	a.AgentRuns = append(a.AgentRuns, runs...)
+	a.AddUsage(runs...)
+	return rev, nil
+}
+
+// RegenerateVerdict asks the moderator for a new verdict on the current reports of a finished
+// analysis, e.g. after one of them was rerun. a is updated in place and the replaced verdict is
+// returned as a revision. The calls are reported to done.
+func (o *Orchestrator) RegenerateVerdict(ctx context.Context, a *models.Analysis, done AgentDoneFunc) (*models.Revision, error) {
+	reports := make(map[agents.Role]models.Report, len(a.Reports))
+	for _, r := range a.Reports {
+		reports[agents.Role(r.Role)] = r
+	}
+
+	t := newTracker(o.prices(), done)
+	moderator, verdict, err := o.decide(ctx, t, models.StageRerun, ideaWithBrief(a.IdeaText, a.Brief), reports, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	rev := a.ReplaceVerdict(o.board.Moderator().ID, moderator, verdict)
+	runs := t.result()
+	a.AgentRuns = append(a.AgentRuns, runs...)
+	a.AddUsage(runs...)
+	return rev, nil
+}
+
+// agentWithModel returns the agent of role, or a one-off agent of the same role on model.
+func (o *Orchestrator) agentWithModel(r board.Role, model string) (agents.Agent, error) {
+	if model == "" || model == r.Model {
+		ag, ok := o.agents[agents.Role(r.ID)]
+		if !ok {
+			return nil, fmt.Errorf("%s agent not initialized", r.ID)
+		}
+		return ag, nil
+	}
+	r.Model = model
+	r.FallbackModels = nil
+	return agents.NewAgent(o.providers, r)
+}
@@ -0,0 +1,254 @@
+package orchestrator
+
+import (
+	"BoardAI/internal/agents"
+	"BoardAI/internal/board"
+	"BoardAI/internal/llm"
+	"BoardAI/internal/models"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+)
+
+const (
+	// runesPerToken — грубая оценка для русского текста; точный токенизатор у каждой модели свой.
+	runesPerToken = 3
+	// promptReserve оставляет место под заголовки промпта и служебные токены.
+	promptReserve = 256
+	// minReportBudget не дает бюджету схлопнуться до нуля на маленьком контексте.
+	minReportBudget = 150
+)
+
+// estimateTokens roughly estimates how many tokens text takes.
+func estimateTokens(s string) int {
+	return (len([]rune(s)) + runesPerToken - 1) / runesPerToken
+}
+
+// newSummarizer builds the agent that condenses reports. It reuses the moderator's provider and
+// models, since whatever the moderator can read fits that model's context anyway.
+func newSummarizer(providers llm.Registry, moderator board.Role) (agents.Agent, error) {
+	zero := float32(0)
+	r := moderator
+	r.ID = "summarizer"
+	r.Prompt = llm.SystemPromptSummarizer
+	r.Temperature = &zero
+	return agents.NewAgent(providers, r)
+}
+
+// reportBudget returns how many tokens each expert report may take in the moderator prompt.
+func (o *Orchestrator) reportBudget(idea string, experts int) int {
+	m := o.board.Moderator()
+	free := m.ContextWindow - m.MaxTokens - promptReserve -
+		estimateTokens(m.Prompt) - estimateTokens(llm.VerdictJSONInstruction) - estimateTokens(idea)
+	if experts > 0 {
+		free /= experts
+	}
+	if free < minReportBudget {
+		return minReportBudget
+	}
+	return free
+}
+
+// chunkBudget returns how many tokens of a report fit into one summarizer call.
+func (o *Orchestrator) chunkBudget() int {
+	m := o.board.Moderator()
+	free := m.ContextWindow - m.MaxTokens - promptReserve -
+		estimateTokens(llm.SystemPromptSummarizer) - estimateTokens(llm.SummaryJSONInstruction)
+	if free < minReportBudget {
+		return minReportBudget
+	}
+	return free
+}
+
+// summarizeReports condenses the reports that go to the moderator so that all of them fit its
+// context window. Reports within the budget are passed through as is; longer ones are split into
+// chunks, each chunk is summarized (map) and the partial summaries are merged (reduce).
+// The result is keyed by role and holds the text to put into the moderator prompt.
+// Summarizer calls are recorded in t under the role whose report they condense.
+func (o *Orchestrator) summarizeReports(ctx context.Context, t *tracker, idea string, reports map[agents.Role]models.Report) map[agents.Role]string {
+	var roles []board.Role
+	for _, r := range o.board.Experts() {
+		if r.ReportsToModerator() {
+			roles = append(roles, r)
+		}
+	}
+	budget := o.reportBudget(idea, len(roles))
+
+	workers := 1
+	if o.cfg != nil && o.cfg.MaxParallelAgents > 1 {
+		workers = o.cfg.MaxParallelAgents
+	}
+	sem := make(chan struct{}, workers)
+
+	// Короткие отчеты кладем в result до запуска горутин: дальше карту пишут только они, под mu.
+	result := make(map[agents.Role]string, len(roles))
+	var long []board.Role
+	for _, r := range roles {
+		content := reports[agents.Role(r.ID)].Content
+		if estimateTokens(content) <= budget {
+			result[agents.Role(r.ID)] = content
+			continue
+		}
+		long = append(long, r)
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, r := range long {
+		wg.Add(1)
+		go func(r board.Role, content string) {
+			defer wg.Done()
+			callCtx, call := t.start(ctx, r.ID, models.StageSummary, 0)
+			text, err := o.condense(callCtx, sem, r, content, budget)
+			if err != nil {
+				log.Printf("%s summary error: %v", r.ID, err)
+			}
+			t.finish(call, err)
+			mu.Lock()
+			result[agents.Role(r.ID)] = text
+			mu.Unlock()
+		}(r, reports[agents.Role(r.ID)].Content)
+	}
+	wg.Wait()
+
+	return result
+}
+
+// condense summarizes one report down to budget tokens, truncating as the last resort.
+// The text is always usable; the error joins the summarizer calls that failed on the way.
+func (o *Orchestrator) condense(ctx context.Context, sem chan struct{}, r board.Role, content string, budget int) (string, error) {
+	chunks := splitChunks(content, o.chunkBudget()*runesPerToken)
+
+	parts := make([]*models.ReportSummary, len(chunks))
+	errs := make([]error, len(chunks))
+	var wg sync.WaitGroup
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func(i int, chunk string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			s, err := o.summarize(ctx, r, chunk)
+			if err != nil {
+				errs[i] = fmt.Errorf("chunk %d/%d: %w", i+1, len(chunks), err)
+				return
+			}
+			parts[i] = s
+		}(i, chunk)
+	}
+	wg.Wait()
+
+	merged := mergeSummaries(parts)
+	if merged == nil {
+		return truncateTokens(content, budget), errors.Join(errs...)
+	}
+	text := renderSummary(merged)
+
+	// Склейка нескольких кусков может снова не влезть — сжимаем ее еще раз.
+	if estimateTokens(text) > budget && len(chunks) > 1 {
+		sem <- struct{}{}
+		s, err := o.summarize(ctx, r, text)
+		<-sem
+		if err != nil {
+			errs = append(errs, fmt.Errorf("reduce: %w", err))
+		} else {
+			text = renderSummary(s)
+		}
+	}
+	return truncateTokens(text, budget), errors.Join(errs...)
+}
+
+func (o *Orchestrator) summarize(ctx context.Context, r board.Role, text string) (*models.ReportSummary, error) {
+	prompt := fmt.Sprintf("Сожми отчет эксперта «%s» до ключевых тезисов.\n\nОТЧЕТ:\n%s\n\n%s",
+		r.Name, text, llm.SummaryJSONInstruction)
+
+	var s models.ReportSummary
+	if _, err := o.summarizer.RunJSON(ctx, prompt, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// mergeSummaries concatenates chunk summaries in order; the stance of the last chunk wins,
+// since conclusions usually come at the end of a report. Failed chunks are skipped.
+func mergeSummaries(parts []*models.ReportSummary) *models.ReportSummary {
+	var merged *models.ReportSummary
+	for _, p := range parts {
+		if p == nil {
+			continue
+		}
+		if merged == nil {
+			merged = &models.ReportSummary{}
+		}
+		merged.Stance = p.Stance
+		merged.KeyPoints = append(merged.KeyPoints, p.KeyPoints...)
+		merged.Figures = append(merged.Figures, p.Figures...)
+	}
+	return merged
+}
+
+func renderSummary(s *models.ReportSummary) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Позиция: %s\n", s.Stance)
+	sb.WriteString("Тезисы:\n")
+	writeList(&sb, s.KeyPoints)
+	if len(s.Figures) > 0 {
+		sb.WriteString("Цифры:\n")
+		writeList(&sb, s.Figures)
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// splitChunks splits text into pieces of at most maxRunes runes, preferring paragraph and then
+// line boundaries.
+func splitChunks(text string, maxRunes int) []string {
+	var chunks []string
+	var cur []rune
+	flush := func() {
+		if s := strings.TrimSpace(string(cur)); s != "" {
+			chunks = append(chunks, s)
+		}
+		cur = cur[:0]
+	}
+
+	for _, para := range strings.SplitAfter(text, "\n\n") {
+		p := []rune(para)
+		if len(cur)+len(p) > maxRunes {
+			flush()
+		}
+		for len(p) > maxRunes {
+			cut := maxRunes
+			if i := lastIndexRune(p[:maxRunes], '\n'); i > 0 {
+				cut = i + 1
+			}
+			cur = append(cur, p[:cut]...)
+			flush()
+			p = p[cut:]
+		}
+		cur = append(cur, p...)
+	}
+	flush()
+	return chunks
+}
+
+func lastIndexRune(rs []rune, r rune) int {
+	for i := len(rs) - 1; i >= 0; i-- {
+		if rs[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// truncateTokens cuts text to roughly maxTokens tokens without breaking runes.
+func truncateTokens(s string, maxTokens int) string {
+	rs := []rune(s)
+	maxRunes := maxTokens * runesPerToken
+	if len(rs) <= maxRunes {
+		return s
+	}
+	return string(rs[:maxRunes]) + "... [текст сокращен]"
+}
@@ -0,0 +1,76 @@
+package orchestrator
+
+import (
+	"BoardAI/internal/llm"
+	"BoardAI/internal/models"
+	"context"
+	"sync"
+	"time"
+)
+
+// AgentDoneFunc is notified every time an agent finishes its part of the analysis, successfully or not.
+type AgentDoneFunc func(run models.AgentRun)
+
+// tracker collects the agent runs of one analysis with their timing and LLM usage;
+// it is safe for concurrent use.
+type tracker struct {
+	prices llm.PriceTable
+	done   AgentDoneFunc
+
+	mu   sync.Mutex
+	runs []models.AgentRun
+}
+
+func newTracker(prices llm.PriceTable, done AgentDoneFunc) *tracker {
+	return &tracker{prices: prices, done: done}
+}
+
+// agentCall is one agent call being measured.
+type agentCall struct {
+	role    string
+	stage   string
+	round   int
+	started time.Time
+	meter   *llm.Meter
+}
+
+// start begins measuring a call of role; the LLM requests made with the returned context are
+// counted towards it.
+func (t *tracker) start(ctx context.Context, role, stage string, round int) (context.Context, *agentCall) {
+	c := &agentCall{role: role, stage: stage, round: round, started: time.Now(), meter: llm.NewMeter(t.prices)}
+	return llm.WithMeter(ctx, c.meter), c
+}
+
+// finish stores the run and passes it to the done callback.
+func (t *tracker) finish(c *agentCall, err error) {
+	usage, cost := c.meter.Total()
+	run := models.AgentRun{
+		Role:             c.role,
+		Stage:            c.stage,
+		Round:            c.round,
+		Model:            usage.Model,
+		StartedAt:        c.started,
+		DurationMs:       time.Since(c.started).Milliseconds(),
+		LatencyMs:        usage.Latency.Milliseconds(),
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		CostUSD:          cost,
+	}
+	if err != nil {
+		run.Error = err.Error()
+	}
+
+	t.mu.Lock()
+	t.runs = append(t.runs, run)
+	t.mu.Unlock()
+
+	if t.done != nil {
+		t.done(run)
+	}
+}
+
+func (t *tracker) result() []models.AgentRun {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]models.AgentRun(nil), t.runs...)
+}
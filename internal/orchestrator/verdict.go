@@ -0,0 +1,99 @@
+package orchestrator
+
+import (
+	"BoardAI/internal/agents"
+	"BoardAI/internal/board"
+	"BoardAI/internal/llm"
+	"BoardAI/internal/models"
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// runModerator asks the moderator for a structured JSON verdict. If the model cannot produce
+// a valid one, it falls back to a free-text verdict and returns a nil Verdict.
+func (o *Orchestrator) runModerator(ctx context.Context, ag agents.Agent, prompt string, progress ProgressFunc) (string, *models.Verdict, error) {
+	var v models.Verdict
+	_, err := ag.RunJSON(ctx, prompt+"\n\n"+llm.VerdictJSONInstruction, &v)
+	if err == nil {
+		text := renderVerdict(&v)
+		if progress != nil {
+			progress(ag.Role(), text)
+		}
+		return text, &v, nil
+	}
+	if ctx.Err() != nil {
+		return "", nil, err
+	}
+	log.Printf("moderator structured verdict error: %v", err)
+
+	text, err := runAgent(ctx, ag, prompt, progress)
+	if err != nil {
+		return "", nil, err
+	}
+	return text, nil, nil
+}
+
+// runExpert runs one expert; structured experts answer with a JSON assessment and fall back
+// to free text if it cannot be parsed.
+func runExpert(ctx context.Context, r board.Role, ag agents.Agent, input string, progress ProgressFunc) (models.Report, error) {
+	if r.Structured {
+		var a models.Assessment
+		_, err := ag.RunJSON(ctx, input+"\n\n"+llm.AssessmentJSONInstruction, &a)
+		if err == nil {
+			text := renderAssessment(&a)
+			if progress != nil {
+				progress(ag.Role(), text)
+			}
+			return models.Report{Role: r.ID, Content: text, Assessment: &a}, nil
+		}
+		if ctx.Err() != nil {
+			return models.Report{}, err
+		}
+		log.Printf("%s structured assessment error: %v", r.ID, err)
+	}
+
+	text, err := runAgent(ctx, ag, input, progress)
+	if err != nil {
+		return models.Report{}, err
+	}
+	return models.Report{Role: r.ID, Content: text}, nil
+}
+
+func renderVerdict(v *models.Verdict) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "1. КРАТКИЙ ВЕРДИКТ: %s (оценка %d/100)\n%s\n\n", v.Decision.Title(), v.Score, v.Summary)
+
+	sb.WriteString("2. ГЛАВНЫЕ РИСКИ:\n")
+	writeList(&sb, v.Risks)
+
+	sb.WriteString("\n3. КЛЮЧЕВЫЕ РЕКОМЕНДАЦИИ:\n")
+	writeList(&sb, v.Recommendations)
+
+	sb.WriteString("\nОЦЕНКИ ПО НАПРАВЛЕНИЯМ:\n")
+	for _, d := range models.VerdictDimensions {
+		fmt.Fprintf(&sb, "• %s: %d/100\n", models.DimensionTitle(d), v.Dimensions[d])
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func renderAssessment(a *models.Assessment) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s\n\nОценка: %d/100\n", a.Summary, a.Score)
+	if len(a.Risks) > 0 {
+		sb.WriteString("\nРиски:\n")
+		writeList(&sb, a.Risks)
+	}
+	if len(a.Recommendations) > 0 {
+		sb.WriteString("\nРекомендации:\n")
+		writeList(&sb, a.Recommendations)
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+func writeList(sb *strings.Builder, items []string) {
+	for i, item := range items {
+		fmt.Fprintf(sb, "%d) %s\n", i+1, item)
+	}
+}
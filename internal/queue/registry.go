@@ -0,0 +1,44 @@
+package queue
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrCancelled is the cancellation cause of a job stopped by its user.
+var ErrCancelled = errors.New("analysis cancelled by user")
+
+// Registry keeps the cancel funcs of the jobs running in this process, so a cancel request
+// handled by the same process stops the job at once instead of waiting for the next DB poll.
+type Registry struct {
+	mu      sync.Mutex
+	cancels map[int64]context.CancelCauseFunc
+}
+
+func NewRegistry() *Registry {
+	return &Registry{cancels: make(map[int64]context.CancelCauseFunc)}
+}
+
+func (r *Registry) add(jobID int64, cancel context.CancelCauseFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cancels[jobID] = cancel
+}
+
+func (r *Registry) remove(jobID int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.cancels, jobID)
+}
+
+// Cancel aborts the job if it runs in this process and reports whether it did.
+func (r *Registry) Cancel(jobID int64) bool {
+	r.mu.Lock()
+	cancel, ok := r.cancels[jobID]
+	r.mu.Unlock()
+	if ok {
+		cancel(ErrCancelled)
+	}
+	return ok
+}
@@ -0,0 +1,291 @@
+package queue
+
+import (
+	"BoardAI/internal/models"
+	"BoardAI/internal/orchestrator"
+	"BoardAI/internal/repository"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	pollInterval      = 2 * time.Second
+	heartbeatInterval = 30 * time.Second
+	// Отмену, запрошенную через другую реплику, воркер замечает по флагу в БД не позже чем через cancelPollInterval.
+	cancelPollInterval = 5 * time.Second
+	// Задача считается брошенной, если воркер не присылал heartbeat дольше staleAfter.
+	staleAfter     = 2 * time.Minute
+	staleCheckTick = time.Minute
+	jobTimeout     = 20 * time.Minute
+	retryDelay     = 30 * time.Second
+)
+
+// Notifier tells the user what happens with their job.
+type Notifier interface {
+	// JobStarted is called when a worker picks the job up; the returned progress func may be nil.
+	JobStarted(job *models.Job) orchestrator.ProgressFunc
+	JobCompleted(job *models.Job, analysis *models.Analysis)
+	// JobFailed is called on every failed attempt; willRetry tells whether the job goes back to the queue.
+	JobFailed(job *models.Job, err error, willRetry bool)
+	// JobCancelled is called when a running job stops because its user cancelled it.
+	JobCancelled(job *models.Job)
+}
+
+// Worker claims analysis jobs of one source from Postgres and runs them through the orchestrator.
+// Every run is recorded in the analyses table from the moment it starts.
+type Worker struct {
+	jobs        repository.JobRepository
+	analyses    repository.AnalysisRepository
+	orc         *orchestrator.Orchestrator
+	notifier    Notifier
+	running     *Registry
+	source      models.JobSource
+	id          string
+	concurrency int
+}
+
+// NewWorker creates a worker; the jobs it runs are registered in running so they can be cancelled.
+func NewWorker(
+	jobs repository.JobRepository,
+	analyses repository.AnalysisRepository,
+	orc *orchestrator.Orchestrator,
+	notifier Notifier,
+	running *Registry,
+	source models.JobSource,
+	concurrency int,
+) *Worker {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	host, _ := os.Hostname()
+	return &Worker{
+		jobs:        jobs,
+		analyses:    analyses,
+		orc:         orc,
+		notifier:    notifier,
+		running:     running,
+		source:      source,
+		id:          fmt.Sprintf("%s-%d", host, os.Getpid()),
+		concurrency: concurrency,
+	}
+}
+
+// Run processes jobs until ctx is cancelled. Jobs interrupted by shutdown go back to the queue,
+// and jobs abandoned by a crashed process are picked up again once their heartbeat goes stale.
+func (w *Worker) Run(ctx context.Context) {
+	log.Printf("job worker %s started with %d slot(s) for %s jobs", w.id, w.concurrency, w.source)
+
+	w.requeueStale(ctx)
+
+	var wg sync.WaitGroup
+	for i := 0; i < w.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w.loop(ctx)
+		}()
+	}
+
+	ticker := time.NewTicker(staleCheckTick)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			log.Printf("job worker %s stopped", w.id)
+			return
+		case <-ticker.C:
+			w.requeueStale(ctx)
+		}
+	}
+}
+
+func (w *Worker) loop(ctx context.Context) {
+	for {
+		job, err := w.jobs.Claim(ctx, w.source, w.id)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("claim job error: %v", err)
+		}
+
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(pollInterval):
+				continue
+			}
+		}
+
+		w.process(ctx, job)
+	}
+}
+
+func (w *Worker) process(ctx context.Context, job *models.Job) {
+	log.Printf("job %d: attempt %d/%d for user %d", job.ID, job.Attempts, job.MaxAttempts, job.UserID)
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, jobTimeout)
+	defer cancel()
+	jobCtx, cancelJob := context.WithCancelCause(timeoutCtx)
+	defer cancelJob(nil)
+	w.running.add(job.ID, cancelJob)
+	defer w.running.remove(job.ID)
+
+	w.startAnalysis(jobCtx, job)
+
+	stopHeartbeat := w.heartbeat(jobCtx, job.ID, cancelJob)
+	progress := w.notifier.JobStarted(job)
+	analysis, err := w.orc.RunAnalysis(jobCtx, orchestrator.Input{Idea: job.IdeaText, Brief: job.Brief, Attachments: job.Attachments}, job.UserID, progress, w.agentDone(job))
+	stopHeartbeat()
+
+	// Для записи результата используем отдельный контекст: при остановке бота ctx уже отменен.
+	dbCtx, dbCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer dbCancel()
+
+	if err != nil {
+		if errors.Is(context.Cause(jobCtx), ErrCancelled) {
+			log.Printf("job %d: cancelled by user", job.ID)
+			if err := w.jobs.MarkCancelled(dbCtx, job.ID); err != nil {
+				log.Printf("job %d: %v", job.ID, err)
+			}
+			w.finishAnalysis(dbCtx, job, models.AnalysisCancelled, ErrCancelled.Error())
+			w.notifier.JobCancelled(job)
+			return
+		}
+		if ctx.Err() != nil {
+			// Анализ остается running: задача вернется в очередь и перезапустит его.
+			log.Printf("job %d: interrupted by shutdown, returning to queue", job.ID)
+			if err := w.jobs.Release(dbCtx, job.ID); err != nil {
+				log.Printf("job %d: release error: %v", job.ID, err)
+			}
+			return
+		}
+
+		log.Printf("job %d: RunAnalysis error: %v", job.ID, err)
+		willRetry, ferr := w.jobs.Fail(dbCtx, job.ID, err.Error(), retryDelay*time.Duration(job.Attempts))
+		if ferr != nil {
+			log.Printf("job %d: fail error: %v", job.ID, ferr)
+		}
+		if !willRetry {
+			w.finishAnalysis(dbCtx, job, models.AnalysisFailed, err.Error())
+		}
+		w.notifier.JobFailed(job, err, willRetry)
+		return
+	}
+
+	if job.AnalysisID != 0 {
+		analysis.ID = job.AnalysisID
+		if err := w.analyses.Complete(dbCtx, analysis); err != nil {
+			log.Printf("job %d: complete analysis error: %v", job.ID, err)
+		}
+	}
+	if err := w.jobs.Complete(dbCtx, job.ID, analysis); err != nil {
+		log.Printf("job %d: complete error: %v", job.ID, err)
+	}
+	w.notifier.JobCompleted(job, analysis)
+}
+
+// startAnalysis records the run in the analyses table and links it to the job. A retried job
+// reuses the analysis of its previous attempt. Errors are only logged: the run goes on without history.
+func (w *Worker) startAnalysis(ctx context.Context, job *models.Job) {
+	a := &models.Analysis{ID: job.AnalysisID, UserID: job.UserID, IdeaText: job.IdeaText, Brief: job.Brief, Attachments: job.Attachments}
+	if err := w.analyses.Start(ctx, a); err != nil {
+		log.Printf("job %d: %v", job.ID, err)
+		return
+	}
+	if a.ID == job.AnalysisID {
+		return
+	}
+	if err := w.jobs.SetAnalysis(ctx, job.ID, a.ID); err != nil {
+		log.Printf("job %d: %v", job.ID, err)
+		return
+	}
+	job.AnalysisID = a.ID
+}
+
+// agentDone saves every agent's timing as soon as it finishes.
+func (w *Worker) agentDone(job *models.Job) orchestrator.AgentDoneFunc {
+	if job.AnalysisID == 0 {
+		return nil
+	}
+	return func(run models.AgentRun) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := w.analyses.AddAgentRun(ctx, job.AnalysisID, run); err != nil {
+			log.Printf("job %d: %v", job.ID, err)
+		}
+	}
+}
+
+func (w *Worker) finishAnalysis(ctx context.Context, job *models.Job, status models.AnalysisStatus, errMsg string) {
+	if job.AnalysisID == 0 {
+		return
+	}
+	if err := w.analyses.Finish(ctx, job.AnalysisID, status, errMsg); err != nil {
+		log.Printf("job %d: %v", job.ID, err)
+	}
+}
+
+// heartbeat periodically marks the job as alive and checks whether its user asked to cancel it,
+// calling cancel with ErrCancelled if so. It runs until the returned stop func is called.
+func (w *Worker) heartbeat(ctx context.Context, id int64, cancel context.CancelCauseFunc) func() {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+		cancelTicker := time.NewTicker(cancelPollInterval)
+		defer cancelTicker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := w.jobs.Heartbeat(ctx, id, w.id); err != nil {
+					log.Printf("job %d: %v", id, err)
+				}
+			case <-cancelTicker.C:
+				requested, err := w.jobs.CancelRequested(ctx, id)
+				if err != nil {
+					log.Printf("job %d: %v", id, err)
+					continue
+				}
+				if requested {
+					cancel(ErrCancelled)
+					return
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+func (w *Worker) requeueStale(ctx context.Context) {
+	finished, err := w.jobs.RequeueStale(ctx, w.source, staleAfter)
+	if err != nil {
+		if ctx.Err() == nil {
+			log.Printf("requeue stale jobs error: %v", err)
+		}
+		return
+	}
+	for _, job := range finished {
+		if job.Status == models.JobCancelled {
+			w.finishAnalysis(ctx, job, models.AnalysisCancelled, ErrCancelled.Error())
+			w.notifier.JobCancelled(job)
+			continue
+		}
+		w.finishAnalysis(ctx, job, models.AnalysisFailed, job.LastError)
+		w.notifier.JobFailed(job, fmt.Errorf("%s", job.LastError), false)
+	}
+}
@@ -0,0 +1,109 @@
+package quota
+
+import (
+	"BoardAI/internal/models"
+	"BoardAI/internal/repository"
+	"context"
+	"time"
+)
+
+// Limits are the analysis quotas; zero disables a limit.
+type Limits struct {
+	// MaxConcurrent — сколько анализов пользователя могут одновременно ждать в очереди или выполняться.
+	MaxConcurrent int
+	// PerDay — сколько анализов пользователь может запустить за сутки (до полуночи по времени сервера).
+	PerDay int
+	// MaxQueueDepth — сколько задач всех пользователей может ждать в очереди.
+	MaxQueueDepth int
+}
+
+// Reason tells which limit refused an analysis.
+type Reason string
+
+const (
+	ReasonNone       Reason = ""
+	ReasonConcurrent Reason = "concurrent"
+	ReasonDaily      Reason = "daily"
+	ReasonQueueFull  Reason = "queue_full"
+)
+
+// Status is the user's quota at the moment of the check.
+type Status struct {
+	// Exempt — пользователь из белого списка или администратор: лимиты к нему не применяются.
+	Exempt bool
+	Reason Reason
+	Limits Limits
+
+	Active     int
+	UsedToday  int
+	QueueDepth int
+	// ResetAt — когда обнуляется дневной лимит.
+	ResetAt time.Time
+}
+
+// Allowed reports whether the user may start one more analysis.
+func (s Status) Allowed() bool {
+	return s.Reason == ReasonNone
+}
+
+// RemainingToday returns how many analyses the user may still start today, or -1 without a daily limit.
+func (s Status) RemainingToday() int {
+	if s.Exempt || s.Limits.PerDay <= 0 {
+		return -1
+	}
+	if left := s.Limits.PerDay - s.UsedToday; left > 0 {
+		return left
+	}
+	return 0
+}
+
+// Limiter checks the quotas against the jobs of one frontend.
+type Limiter struct {
+	jobs   repository.JobRepository
+	source models.JobSource
+	limits Limits
+	exempt func(userID int64) bool
+}
+
+func NewLimiter(jobs repository.JobRepository, source models.JobSource, limits Limits, exempt func(userID int64) bool) *Limiter {
+	return &Limiter{jobs: jobs, source: source, limits: limits, exempt: exempt}
+}
+
+// Check returns the user's quota. Limits are checked in order — concurrent analyses, the daily
+// limit, the global queue — and the first exceeded one is reported.
+func (l *Limiter) Check(ctx context.Context, userID int64) (Status, error) {
+	st := Status{Limits: l.limits}
+	if l.exempt != nil && l.exempt(userID) {
+		st.Exempt = true
+		return st, nil
+	}
+
+	var err error
+	if st.Active, err = l.jobs.CountActiveByUser(ctx, l.source, userID); err != nil {
+		return st, err
+	}
+	dayStart := StartOfDay(time.Now())
+	st.ResetAt = dayStart.AddDate(0, 0, 1)
+	if st.UsedToday, err = l.jobs.CountByUserSince(ctx, l.source, userID, dayStart); err != nil {
+		return st, err
+	}
+	if st.QueueDepth, err = l.jobs.QueueDepth(ctx); err != nil {
+		return st, err
+	}
+
+	switch {
+	case l.limits.MaxConcurrent > 0 && st.Active >= l.limits.MaxConcurrent:
+		st.Reason = ReasonConcurrent
+	case l.limits.PerDay > 0 && st.UsedToday >= l.limits.PerDay:
+		st.Reason = ReasonDaily
+	case l.limits.MaxQueueDepth > 0 && st.QueueDepth >= l.limits.MaxQueueDepth:
+		st.Reason = ReasonQueueFull
+	}
+	return st, nil
+}
+
+// StartOfDay returns the midnight of t's day: daily quotas and the "today" usage start there.
+func StartOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
@@ -4,13 +4,39 @@ import (
 	"BoardAI/internal/models"
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 )
 
 type AnalysisRepository interface {
 	Create(ctx context.Context, a *models.Analysis) error
+	// Start records a running analysis before the agents start. If a.ID is already set (a retried
+	// job), the existing row is reset to running instead.
+	Start(ctx context.Context, a *models.Analysis) error
+	// AddAgentRun appends one agent's timing to a running analysis, adds its tokens and cost to
+	// the analysis totals and records it in the llm_usage ledger.
+	AddAgentRun(ctx context.Context, id int64, run models.AgentRun) error
+	// Complete stores the result of the analysis with id a.ID and marks it completed.
+	Complete(ctx context.Context, a *models.Analysis) error
+	// Finish marks the analysis failed or cancelled with the given error message.
+	Finish(ctx context.Context, id int64, status models.AnalysisStatus, errMsg string) error
+	// SetPinned changes the favourite flag of the user's analysis and reports whether it was found.
+	SetPinned(ctx context.Context, id, userID int64, pinned bool) (bool, error)
 	Get(ctx context.Context, id int64) (*models.Analysis, error)
 	List(ctx context.Context, limit, offset int) ([]*models.Analysis, error)
+	// ListByUser returns the user's analyses, pinned ones first.
+	ListByUser(ctx context.Context, userID int64, limit, offset int) ([]*models.Analysis, error)
+	// GetFromSource returns the analysis only if it was run by a job from source; nil otherwise.
+	GetFromSource(ctx context.Context, id int64, source models.JobSource) (*models.Analysis, error)
+	// ListByUserFromSource returns the user's analyses run by jobs from source, newest first.
+	ListByUserFromSource(ctx context.Context, userID int64, source models.JobSource, limit, offset int) ([]*models.Analysis, error)
+	// Delete removes the analysis only if it belongs to userID and reports whether a row was deleted.
+	Delete(ctx context.Context, id, userID int64) (bool, error)
+	// Revise stores the updated result of the completed analysis a together with rev, the version
+	// of a report or of the verdict it replaced, in one transaction.
+	Revise(ctx context.Context, a *models.Analysis, rev *models.Revision) error
+	// Revisions returns the replaced versions of the analysis's reports and verdict, oldest first.
+	Revisions(ctx context.Context, analysisID int64) ([]*models.Revision, error)
 }
 
 type analysisRepository struct {
@@ -21,36 +47,102 @@ func NewAnalysisRepository(db *sql.DB) AnalysisRepository {
 	return &analysisRepository{db: db}
 }
 
+const analysisColumns = `
+			id,
+			user_id,
+			idea_text,
+			brief,
+			attachments::text                        AS attachments,
+			COALESCE(reports::text, '')              AS reports,
+			COALESCE(moderator->>'content', '')      AS moderator,
+			COALESCE(debate::text, '')               AS debate,
+			decision,
+			score,
+			COALESCE(moderator->'verdict'->>'summary', '') AS verdict_summary,
+			COALESCE(risks::text, '')                AS risks,
+			COALESCE(recommendations::text, '')      AS recommendations,
+			COALESCE(dimension_scores::text, '')     AS dimension_scores,
+			status,
+			COALESCE(error, '')                      AS error,
+			started_at,
+			finished_at,
+			agent_runs::text                         AS agent_runs,
+			prompt_tokens,
+			completion_tokens,
+			cost_usd::float8                         AS cost_usd,
+			pinned,
+			created_at`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
 func (r *analysisRepository) Create(ctx context.Context, a *models.Analysis) error {
 	query := `
 		INSERT INTO analyses (
 			user_id,
 			idea_text,
-			strategist,
-			financier,
-			auditor,
-			analyst,
-			moderator
-		) VALUES ($1, $2, $3::jsonb, $4::jsonb, $5::jsonb, $6::jsonb, $7::jsonb)
+			reports,
+			moderator,
+			debate,
+			decision,
+			score,
+			risks,
+			recommendations,
+			dimension_scores,
+			status,
+			error,
+			started_at,
+			finished_at,
+			agent_runs,
+			prompt_tokens,
+			completion_tokens,
+			cost_usd,
+			pinned,
+			attachments,
+			brief
+		) VALUES ($1, $2, $3::jsonb, $4::jsonb, $5::jsonb, $6, $7, $8::jsonb, $9::jsonb, $10::jsonb,
+			$11, NULLIF($12, ''), $13, $14, $15::jsonb, $16, $17, $18, $19, $20::jsonb, $21)
 		RETURNING id, created_at
 	`
 
-	strategistJSON := fmt.Sprintf(`{"role":"strategist","content":%q}`, a.Strategist)
-	financierJSON := fmt.Sprintf(`{"role":"financier","content":%q}`, a.Financier)
-	auditorJSON := fmt.Sprintf(`{"role":"auditor","content":%q}`, a.Auditor)
-	analystJSON := fmt.Sprintf(`{"role":"analyst","content":%q}`, a.Analyst)
-	moderatorJSON := fmt.Sprintf(`{"role":"moderator","content":%q}`, a.Moderator)
+	c, err := encodeResult(a)
+	if err != nil {
+		return err
+	}
+	attachments, err := encodeAttachments(a.Attachments)
+	if err != nil {
+		return err
+	}
+	if a.Status == "" {
+		a.Status = models.AnalysisCompleted
+	}
 
-	err := r.db.QueryRowContext(
+	err = r.db.QueryRowContext(
 		ctx,
 		query,
 		a.UserID,
 		a.IdeaText,
-		strategistJSON,
-		financierJSON,
-		auditorJSON,
-		analystJSON,
-		moderatorJSON,
+		c.reports,
+		c.moderator,
+		c.debate,
+		c.verdict.decision,
+		c.verdict.score,
+		c.verdict.risks,
+		c.verdict.recommendations,
+		c.verdict.dimensions,
+		a.Status,
+		a.Error,
+		a.StartedAt,
+		a.FinishedAt,
+		c.agentRuns,
+		a.PromptTokens,
+		a.CompletionTokens,
+		a.CostUSD,
+		a.Pinned,
+		attachments,
+		a.Brief,
 	).Scan(&a.ID, &a.CreatedAt)
 	if err != nil {
 		return fmt.Errorf("insert analysis: %w", err)
@@ -59,63 +151,189 @@ func (r *analysisRepository) Create(ctx context.Context, a *models.Analysis) err
 	return nil
 }
 
+func (r *analysisRepository) Start(ctx context.Context, a *models.Analysis) error {
+	a.Status = models.AnalysisRunning
+	a.Error = ""
+	a.FinishedAt = nil
+	a.AgentRuns = nil
+	a.PromptTokens, a.CompletionTokens, a.CostUSD = 0, 0, 0
+
+	if a.ID != 0 {
+		err := r.db.QueryRowContext(ctx, `
+			UPDATE analyses
+			SET status = 'running',
+				error = NULL,
+				started_at = NOW(),
+				finished_at = NULL,
+				agent_runs = '[]'::jsonb,
+				prompt_tokens = 0,
+				completion_tokens = 0,
+				cost_usd = 0
+			WHERE id = $1
+			RETURNING started_at
+		`, a.ID).Scan(&a.StartedAt)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("restart analysis: %w", err)
+		}
+		if err == nil {
+			return nil
+		}
+		// Строку успели удалить — заводим анализ заново.
+	}
+
+	attachments, err := encodeAttachments(a.Attachments)
+	if err != nil {
+		return err
+	}
+	err = r.db.QueryRowContext(ctx, `
+		INSERT INTO analyses (user_id, idea_text, brief, attachments, status, started_at)
+		VALUES ($1, $2, $3, $4::jsonb, 'running', NOW())
+		RETURNING id, started_at, created_at
+	`, a.UserID, a.IdeaText, a.Brief, attachments).Scan(&a.ID, &a.StartedAt, &a.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("start analysis: %w", err)
+	}
+	return nil
+}
+
+func (r *analysisRepository) AddAgentRun(ctx context.Context, id int64, run models.AgentRun) error {
+	runJSON, err := json.Marshal([]models.AgentRun{run})
+	if err != nil {
+		return fmt.Errorf("marshal agent run: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID int64
+	err = tx.QueryRowContext(ctx, `
+		UPDATE analyses
+		SET agent_runs = agent_runs || $2::jsonb,
+			prompt_tokens = prompt_tokens + $3,
+			completion_tokens = completion_tokens + $4,
+			cost_usd = cost_usd + $5
+		WHERE id = $1 AND status = 'running'
+		RETURNING user_id
+	`, id, string(runJSON), run.PromptTokens, run.CompletionTokens, run.CostUSD).Scan(&userID)
+	if err == sql.ErrNoRows {
+		// Анализ уже завершен или удален — запоздавший вызов не учитываем.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("add agent run: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO llm_usage (analysis_id, user_id, role, stage, model, prompt_tokens, completion_tokens, cost_usd, latency_ms, duration_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, id, userID, run.Role, run.Stage, run.Model, run.PromptTokens, run.CompletionTokens, run.CostUSD, run.LatencyMs, run.DurationMs)
+	if err != nil {
+		return fmt.Errorf("record llm usage: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit agent run: %w", err)
+	}
+	return nil
+}
+
+func (r *analysisRepository) Complete(ctx context.Context, a *models.Analysis) error {
+	c, err := encodeResult(a)
+	if err != nil {
+		return err
+	}
+
+	err = r.db.QueryRowContext(ctx, `
+		UPDATE analyses
+		SET reports = $2::jsonb,
+			moderator = $3::jsonb,
+			debate = $4::jsonb,
+			decision = $5,
+			score = $6,
+			risks = $7::jsonb,
+			recommendations = $8::jsonb,
+			dimension_scores = $9::jsonb,
+			agent_runs = $10::jsonb,
+			prompt_tokens = $11,
+			completion_tokens = $12,
+			cost_usd = $13,
+			status = 'completed',
+			error = NULL,
+			finished_at = NOW()
+		WHERE id = $1
+		RETURNING started_at, finished_at, pinned, created_at
+	`,
+		a.ID,
+		c.reports,
+		c.moderator,
+		c.debate,
+		c.verdict.decision,
+		c.verdict.score,
+		c.verdict.risks,
+		c.verdict.recommendations,
+		c.verdict.dimensions,
+		c.agentRuns,
+		a.PromptTokens,
+		a.CompletionTokens,
+		a.CostUSD,
+	).Scan(&a.StartedAt, &a.FinishedAt, &a.Pinned, &a.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("complete analysis: %w", err)
+	}
+	a.Status = models.AnalysisCompleted
+	a.Error = ""
+	return nil
+}
+
+func (r *analysisRepository) Finish(ctx context.Context, id int64, status models.AnalysisStatus, errMsg string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE analyses
+		SET status = $2, error = NULLIF($3, ''), finished_at = NOW()
+		WHERE id = $1 AND status = 'running'
+	`, id, status, errMsg)
+	if err != nil {
+		return fmt.Errorf("finish analysis: %w", err)
+	}
+	return nil
+}
+
+func (r *analysisRepository) SetPinned(ctx context.Context, id, userID int64, pinned bool) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `UPDATE analyses SET pinned = $3 WHERE id = $1 AND user_id = $2`, id, userID, pinned)
+	if err != nil {
+		return false, fmt.Errorf("set analysis pinned: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("set analysis pinned rows affected: %w", err)
+	}
+	return n > 0, nil
+}
+
 func (r *analysisRepository) Get(ctx context.Context, id int64) (*models.Analysis, error) {
-	query := `
-		SELECT
-			id,
-			user_id,
-			idea_text,
-			COALESCE(strategist::text, '') AS strategist,
-			COALESCE(financier::text, '') AS financier,
-			COALESCE(auditor::text, '')   AS auditor,
-			COALESCE(analyst::text, '')   AS analyst,
-			COALESCE(moderator::text, '') AS moderator,
-			created_at
+	query := `SELECT` + analysisColumns + `
 		FROM analyses
 		WHERE id = $1
 	`
 
-	row := r.db.QueryRowContext(ctx, query, id)
-	var a models.Analysis
-	if err := row.Scan(
-		&a.ID,
-		&a.UserID,
-		&a.IdeaText,
-		&a.Strategist,
-		&a.Financier,
-		&a.Auditor,
-		&a.Analyst,
-		&a.Moderator,
-		&a.CreatedAt,
-	); err != nil {
+	a, err := scanAnalysis(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, fmt.Errorf("get analysis: %w", err)
 	}
 
-	return &a, nil
+	return a, nil
 }
 
 func (r *analysisRepository) List(ctx context.Context, limit, offset int) ([]*models.Analysis, error) {
-	if limit <= 0 {
-		limit = 10
-	}
-	if offset < 0 {
-		offset = 0
-	}
+	limit, offset = normalizePage(limit, offset)
 
-	query := `
-		SELECT
-			id,
-			user_id,
-			idea_text,
-			COALESCE(strategist::text, '') AS strategist,
-			COALESCE(financier::text, '') AS financier,
-			COALESCE(auditor::text, '')   AS auditor,
-			COALESCE(analyst::text, '')   AS analyst,
-			COALESCE(moderator::text, '') AS moderator,
-			created_at
+	query := `SELECT` + analysisColumns + `
 		FROM analyses
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
@@ -125,25 +343,97 @@ func (r *analysisRepository) List(ctx context.Context, limit, offset int) ([]*mo
 	if err != nil {
 		return nil, fmt.Errorf("list analyses: %w", err)
 	}
+	return collectAnalyses(rows)
+}
+
+func (r *analysisRepository) ListByUser(ctx context.Context, userID int64, limit, offset int) ([]*models.Analysis, error) {
+	limit, offset = normalizePage(limit, offset)
+
+	query := `SELECT` + analysisColumns + `
+		FROM analyses
+		WHERE user_id = $1
+		ORDER BY pinned DESC, created_at DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("list user analyses: %w", err)
+	}
+	return collectAnalyses(rows)
+}
+
+// fromSource restricts a query on analyses to the ones run by a job from source ($1).
+const fromSource = `
+		EXISTS (SELECT 1 FROM analysis_jobs j WHERE j.analysis_id = analyses.id AND j.source = $1)
+`
+
+func (r *analysisRepository) GetFromSource(ctx context.Context, id int64, source models.JobSource) (*models.Analysis, error) {
+	query := `SELECT` + analysisColumns + `
+		FROM analyses
+		WHERE id = $2 AND` + fromSource
+
+	a, err := scanAnalysis(r.db.QueryRowContext(ctx, query, source, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get analysis from source: %w", err)
+	}
+	return a, nil
+}
+
+func (r *analysisRepository) ListByUserFromSource(ctx context.Context, userID int64, source models.JobSource, limit, offset int) ([]*models.Analysis, error) {
+	limit, offset = normalizePage(limit, offset)
+
+	query := `SELECT` + analysisColumns + `
+		FROM analyses
+		WHERE user_id = $2 AND` + fromSource + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT $3 OFFSET $4
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, source, userID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("list user analyses from source: %w", err)
+	}
+	return collectAnalyses(rows)
+}
+
+func (r *analysisRepository) Delete(ctx context.Context, id, userID int64) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM analyses WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("delete analysis: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("delete analysis rows affected: %w", err)
+	}
+	return n > 0, nil
+}
+
+func normalizePage(limit, offset int) (int, int) {
+	if limit <= 0 {
+		limit = 10
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+// collectAnalyses scans and closes rows selected with analysisColumns.
+func collectAnalyses(rows *sql.Rows) ([]*models.Analysis, error) {
 	defer rows.Close()
 
 	var result []*models.Analysis
 	for rows.Next() {
-		var a models.Analysis
-		if err := rows.Scan(
-			&a.ID,
-			&a.UserID,
-			&a.IdeaText,
-			&a.Strategist,
-			&a.Financier,
-			&a.Auditor,
-			&a.Analyst,
-			&a.Moderator,
-			&a.CreatedAt,
-		); err != nil {
+		a, err := scanAnalysis(rows)
+		if err != nil {
 			return nil, fmt.Errorf("scan analysis: %w", err)
 		}
-		result = append(result, &a)
+		result = append(result, a)
 	}
 
 	if err := rows.Err(); err != nil {
@@ -152,3 +442,206 @@ func (r *analysisRepository) List(ctx context.Context, limit, offset int) ([]*mo
 
 	return result, nil
 }
+
+// scanAnalysis reads one row selected with analysisColumns.
+func scanAnalysis(row rowScanner) (*models.Analysis, error) {
+	var a models.Analysis
+	var attachments, reports, debate string
+	var decision sql.NullString
+	var score sql.NullInt64
+	var summary, risks, recommendations, dimensions string
+	var startedAt, finishedAt sql.NullTime
+	var agentRuns string
+	if err := row.Scan(
+		&a.ID,
+		&a.UserID,
+		&a.IdeaText,
+		&a.Brief,
+		&attachments,
+		&reports,
+		&a.Moderator,
+		&debate,
+		&decision,
+		&score,
+		&summary,
+		&risks,
+		&recommendations,
+		&dimensions,
+		&a.Status,
+		&a.Error,
+		&startedAt,
+		&finishedAt,
+		&agentRuns,
+		&a.PromptTokens,
+		&a.CompletionTokens,
+		&a.CostUSD,
+		&a.Pinned,
+		&a.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if startedAt.Valid {
+		a.StartedAt = &startedAt.Time
+	}
+	if finishedAt.Valid {
+		a.FinishedAt = &finishedAt.Time
+	}
+	if err := json.Unmarshal([]byte(agentRuns), &a.AgentRuns); err != nil {
+		return nil, fmt.Errorf("unmarshal agent runs: %w", err)
+	}
+	attached, err := decodeAttachments(attachments)
+	if err != nil {
+		return nil, err
+	}
+	a.Attachments = attached
+
+	if reports != "" {
+		if err := json.Unmarshal([]byte(reports), &a.Reports); err != nil {
+			return nil, fmt.Errorf("unmarshal reports: %w", err)
+		}
+	}
+
+	rounds, err := decodeRounds(debate)
+	if err != nil {
+		return nil, err
+	}
+	a.Rounds = rounds
+
+	if decision.Valid {
+		v := &models.Verdict{
+			Decision: models.Decision(decision.String),
+			Score:    int(score.Int64),
+			Summary:  summary,
+		}
+		for _, f := range []struct {
+			raw string
+			dst any
+		}{
+			{risks, &v.Risks},
+			{recommendations, &v.Recommendations},
+			{dimensions, &v.Dimensions},
+		} {
+			if f.raw == "" {
+				continue
+			}
+			if err := json.Unmarshal([]byte(f.raw), f.dst); err != nil {
+				return nil, fmt.Errorf("unmarshal verdict: %w", err)
+			}
+		}
+		a.Verdict = v
+	}
+
+	return &a, nil
+}
+
+// resultColumns holds the encoded JSON columns of a finished analysis.
+type resultColumns struct {
+	reports   string
+	moderator string
+	debate    any
+	agentRuns string
+	verdict   verdictColumns
+}
+
+func encodeResult(a *models.Analysis) (resultColumns, error) {
+	reportsJSON, err := json.Marshal(a.Reports)
+	if err != nil {
+		return resultColumns{}, fmt.Errorf("marshal reports: %w", err)
+	}
+
+	moderatorJSON, err := json.Marshal(struct {
+		Role    string          `json:"role"`
+		Content string          `json:"content"`
+		Verdict *models.Verdict `json:"verdict,omitempty"`
+	}{Role: "moderator", Content: a.Moderator, Verdict: a.Verdict})
+	if err != nil {
+		return resultColumns{}, fmt.Errorf("marshal moderator: %w", err)
+	}
+
+	runs := a.AgentRuns
+	if runs == nil {
+		runs = []models.AgentRun{}
+	}
+	runsJSON, err := json.Marshal(runs)
+	if err != nil {
+		return resultColumns{}, fmt.Errorf("marshal agent runs: %w", err)
+	}
+
+	verdict, err := encodeVerdictColumns(a.Verdict)
+	if err != nil {
+		return resultColumns{}, err
+	}
+
+	debateJSON, err := encodeRounds(a.Rounds)
+	if err != nil {
+		return resultColumns{}, err
+	}
+
+	return resultColumns{
+		reports:   string(reportsJSON),
+		moderator: string(moderatorJSON),
+		debate:    debateJSON,
+		agentRuns: string(runsJSON),
+		verdict:   verdict,
+	}, nil
+}
+
+// verdictColumns holds values for the dedicated verdict columns; all are nil (NULL) without a verdict.
+type verdictColumns struct {
+	decision        any
+	score           any
+	risks           any
+	recommendations any
+	dimensions      any
+}
+
+func encodeVerdictColumns(v *models.Verdict) (verdictColumns, error) {
+	if v == nil {
+		return verdictColumns{}, nil
+	}
+
+	risks, err := json.Marshal(v.Risks)
+	if err != nil {
+		return verdictColumns{}, fmt.Errorf("marshal risks: %w", err)
+	}
+	recommendations, err := json.Marshal(v.Recommendations)
+	if err != nil {
+		return verdictColumns{}, fmt.Errorf("marshal recommendations: %w", err)
+	}
+	dimensions, err := json.Marshal(v.Dimensions)
+	if err != nil {
+		return verdictColumns{}, fmt.Errorf("marshal dimension scores: %w", err)
+	}
+
+	return verdictColumns{
+		decision:        string(v.Decision),
+		score:           v.Score,
+		risks:           string(risks),
+		recommendations: string(recommendations),
+		dimensions:      string(dimensions),
+	}, nil
+}
+
+// encodeRounds returns the debate transcript as JSON, or nil (SQL NULL) when there was no debate.
+func encodeRounds(rounds []models.DebateRound) (any, error) {
+	if len(rounds) == 0 {
+		return nil, nil
+	}
+	b, err := json.Marshal(rounds)
+	if err != nil {
+		return nil, fmt.Errorf("marshal debate: %w", err)
+	}
+	return string(b), nil
+}
+
+func decodeRounds(raw string) ([]models.DebateRound, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var rounds []models.DebateRound
+	if err := json.Unmarshal([]byte(raw), &rounds); err != nil {
+		return nil, fmt.Errorf("unmarshal debate: %w", err)
+	}
+	return rounds, nil
+}
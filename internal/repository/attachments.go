@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"BoardAI/internal/models"
+	"encoding/json"
+	"fmt"
+)
+
+// encodeAttachments returns the JSONB value of an attachments column; no attachments is "[]".
+func encodeAttachments(list []models.Attachment) (string, error) {
+	if list == nil {
+		list = []models.Attachment{}
+	}
+	data, err := json.Marshal(list)
+	if err != nil {
+		return "", fmt.Errorf("marshal attachments: %w", err)
+	}
+	return string(data), nil
+}
+
+func decodeAttachments(raw string) ([]models.Attachment, error) {
+	if raw == "" || raw == "[]" {
+		return nil, nil
+	}
+	var list []models.Attachment
+	if err := json.Unmarshal([]byte(raw), &list); err != nil {
+		return nil, fmt.Errorf("unmarshal attachments: %w", err)
+	}
+	return list, nil
+}
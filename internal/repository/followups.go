@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"BoardAI/internal/models"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// FollowUpRepository keeps the follow-up conversations with experts, one thread per analysis and role.
+type FollowUpRepository interface {
+	Add(ctx context.Context, m *models.FollowUpMessage) error
+	// Thread returns the last limit messages of the conversation with role about the analysis,
+	// oldest first.
+	Thread(ctx context.Context, analysisID int64, role string, limit int) ([]*models.FollowUpMessage, error)
+}
+
+type followUpRepository struct {
+	db *sql.DB
+}
+
+func NewFollowUpRepository(db *sql.DB) FollowUpRepository {
+	return &followUpRepository{db: db}
+}
+
+func (r *followUpRepository) Add(ctx context.Context, m *models.FollowUpMessage) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO analysis_followups (analysis_id, user_id, role, author, content)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`, m.AnalysisID, m.UserID, m.Role, m.Author, m.Content).Scan(&m.ID, &m.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("add follow-up message: %w", err)
+	}
+	return nil
+}
+
+func (r *followUpRepository) Thread(ctx context.Context, analysisID int64, role string, limit int) ([]*models.FollowUpMessage, error) {
+	limit, _ = normalizePage(limit, 0)
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, analysis_id, user_id, role, author, content, created_at
+		FROM (
+			SELECT *
+			FROM analysis_followups
+			WHERE analysis_id = $1 AND role = $2
+			ORDER BY id DESC
+			LIMIT $3
+		) t
+		ORDER BY id
+	`, analysisID, role, limit)
+	if err != nil {
+		return nil, fmt.Errorf("follow-up thread: %w", err)
+	}
+	defer rows.Close()
+
+	var result []*models.FollowUpMessage
+	for rows.Next() {
+		var m models.FollowUpMessage
+		if err := rows.Scan(&m.ID, &m.AnalysisID, &m.UserID, &m.Role, &m.Author, &m.Content, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan follow-up message: %w", err)
+		}
+		result = append(result, &m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return result, nil
+}
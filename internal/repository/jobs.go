@@ -0,0 +1,406 @@
+package repository
+
+import (
+	"BoardAI/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type JobRepository interface {
+	Enqueue(ctx context.Context, j *models.Job) error
+	Get(ctx context.Context, id int64) (*models.Job, error)
+	// SetAnalysis links the job to the analysis its result was saved as.
+	SetAnalysis(ctx context.Context, id, analysisID int64) error
+	// ActiveByUser returns the user's queued or running job from the source, or nil.
+	ActiveByUser(ctx context.Context, source models.JobSource, userID int64) (*models.Job, error)
+	// CountActiveByUser returns how many queued or running jobs the user has in the source.
+	CountActiveByUser(ctx context.Context, source models.JobSource, userID int64) (int, error)
+	// CountByUserSince returns how many jobs the user queued in the source since the given time;
+	// jobs cancelled before they started are not counted.
+	CountByUserSince(ctx context.Context, source models.JobSource, userID int64, since time.Time) (int, error)
+	// QueueDepth returns how many jobs of all sources wait in the queue.
+	QueueDepth(ctx context.Context) (int, error)
+	// Claim atomically takes the oldest queued job of the source for workerID, or returns nil
+	// when there is none.
+	Claim(ctx context.Context, source models.JobSource, workerID string) (*models.Job, error)
+	Heartbeat(ctx context.Context, id int64, workerID string) error
+	// RequestCancel cancels the user's queued or running job: a queued job is marked cancelled at
+	// once, a running one gets a cancel request its worker acts upon. It returns the updated job,
+	// or nil when the user has no such active job.
+	RequestCancel(ctx context.Context, id, userID int64) (*models.Job, error)
+	// CancelRequested reports whether cancellation of the job has been requested.
+	CancelRequested(ctx context.Context, id int64) (bool, error)
+	// MarkCancelled finishes a running job as cancelled.
+	MarkCancelled(ctx context.Context, id int64) error
+	Complete(ctx context.Context, id int64, result *models.Analysis) error
+	// Fail records the error and either re-queues the job after retryAfter or, when attempts are
+	// exhausted, marks it failed. It reports whether the job will be retried.
+	Fail(ctx context.Context, id int64, errMsg string, retryAfter time.Duration) (bool, error)
+	// Release puts a running job back into the queue without counting the attempt (graceful shutdown).
+	Release(ctx context.Context, id int64) error
+	// RequeueStale re-queues running jobs of the source whose worker stopped sending heartbeats
+	// and returns the jobs that were finished instead: failed when they ran out of attempts or
+	// cancelled when the user asked for it.
+	RequeueStale(ctx context.Context, source models.JobSource, staleAfter time.Duration) ([]*models.Job, error)
+}
+
+type jobRepository struct {
+	db *sql.DB
+}
+
+func NewJobRepository(db *sql.DB) JobRepository {
+	return &jobRepository{db: db}
+}
+
+const jobColumns = `
+			id,
+			source,
+			user_id,
+			chat_id,
+			message_id,
+			COALESCE(callback_url, '') AS callback_url,
+			idea_text,
+			brief,
+			attachments::text          AS attachments,
+			status,
+			attempts,
+			max_attempts,
+			COALESCE(last_error, '')   AS last_error,
+			COALESCE(result::text, '') AS result,
+			COALESCE(analysis_id, 0)   AS analysis_id,
+			created_at,
+			finished_at`
+
+func (r *jobRepository) Enqueue(ctx context.Context, j *models.Job) error {
+	query := `
+		INSERT INTO analysis_jobs (source, user_id, chat_id, message_id, callback_url, idea_text, brief, attachments, max_attempts)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8::jsonb, $9)
+		RETURNING id, status, created_at
+	`
+
+	if j.MaxAttempts <= 0 {
+		j.MaxAttempts = 1
+	}
+	if j.Source == "" {
+		j.Source = models.JobSourceTelegram
+	}
+
+	attachments, err := encodeAttachments(j.Attachments)
+	if err != nil {
+		return err
+	}
+
+	err = r.db.QueryRowContext(ctx, query, j.Source, j.UserID, j.ChatID, j.MessageID, j.CallbackURL, j.IdeaText, j.Brief, attachments, j.MaxAttempts).
+		Scan(&j.ID, &j.Status, &j.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("enqueue job: %w", err)
+	}
+	return nil
+}
+
+func (r *jobRepository) Get(ctx context.Context, id int64) (*models.Job, error) {
+	query := `SELECT` + jobColumns + `
+		FROM analysis_jobs
+		WHERE id = $1
+	`
+
+	j, err := scanJob(r.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("get job: %w", err)
+	}
+	return j, nil
+}
+
+func (r *jobRepository) SetAnalysis(ctx context.Context, id, analysisID int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE analysis_jobs
+		SET analysis_id = $2, updated_at = NOW()
+		WHERE id = $1
+	`, id, analysisID)
+	if err != nil {
+		return fmt.Errorf("set job analysis: %w", err)
+	}
+	return nil
+}
+
+func (r *jobRepository) ActiveByUser(ctx context.Context, source models.JobSource, userID int64) (*models.Job, error) {
+	query := `SELECT` + jobColumns + `
+		FROM analysis_jobs
+		WHERE user_id = $1 AND source = $2 AND status IN ('queued', 'running')
+		ORDER BY id
+		LIMIT 1
+	`
+
+	j, err := scanJob(r.db.QueryRowContext(ctx, query, userID, source))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("active job: %w", err)
+	}
+	return j, nil
+}
+
+func (r *jobRepository) CountActiveByUser(ctx context.Context, source models.JobSource, userID int64) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM analysis_jobs
+		WHERE user_id = $1 AND source = $2 AND status IN ('queued', 'running')
+	`, userID, source).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("count active jobs: %w", err)
+	}
+	return n, nil
+}
+
+func (r *jobRepository) CountByUserSince(ctx context.Context, source models.JobSource, userID int64, since time.Time) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM analysis_jobs
+		WHERE user_id = $1 AND source = $2 AND created_at >= $3
+			AND NOT (status = 'cancelled' AND analysis_id IS NULL)
+	`, userID, source, since).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("count user jobs: %w", err)
+	}
+	return n, nil
+}
+
+func (r *jobRepository) QueueDepth(ctx context.Context) (int, error) {
+	var n int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM analysis_jobs WHERE status = 'queued'`).Scan(&n); err != nil {
+		return 0, fmt.Errorf("queue depth: %w", err)
+	}
+	return n, nil
+}
+
+func (r *jobRepository) Claim(ctx context.Context, source models.JobSource, workerID string) (*models.Job, error) {
+	query := `
+		UPDATE analysis_jobs
+		SET status = 'running',
+			attempts = attempts + 1,
+			locked_by = $1,
+			heartbeat_at = NOW(),
+			updated_at = NOW()
+		WHERE id = (
+			SELECT id
+			FROM analysis_jobs
+			WHERE status = 'queued' AND source = $2 AND run_after <= NOW()
+			ORDER BY run_after, id
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING` + jobColumns
+
+	j, err := scanJob(r.db.QueryRowContext(ctx, query, workerID, source))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("claim job: %w", err)
+	}
+	return j, nil
+}
+
+func (r *jobRepository) Heartbeat(ctx context.Context, id int64, workerID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE analysis_jobs
+		SET heartbeat_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND locked_by = $2 AND status = 'running'
+	`, id, workerID)
+	if err != nil {
+		return fmt.Errorf("job heartbeat: %w", err)
+	}
+	return nil
+}
+
+func (r *jobRepository) RequestCancel(ctx context.Context, id, userID int64) (*models.Job, error) {
+	query := `
+		UPDATE analysis_jobs
+		SET cancel_requested = TRUE,
+			status = CASE WHEN status = 'queued' THEN 'cancelled' ELSE status END,
+			finished_at = CASE WHEN status = 'queued' THEN NOW() ELSE finished_at END,
+			updated_at = NOW()
+		WHERE id = $1 AND user_id = $2 AND status IN ('queued', 'running')
+		RETURNING` + jobColumns
+
+	j, err := scanJob(r.db.QueryRowContext(ctx, query, id, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("request job cancel: %w", err)
+	}
+	return j, nil
+}
+
+func (r *jobRepository) CancelRequested(ctx context.Context, id int64) (bool, error) {
+	var requested bool
+	err := r.db.QueryRowContext(ctx, `SELECT cancel_requested FROM analysis_jobs WHERE id = $1`, id).Scan(&requested)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("check job cancel: %w", err)
+	}
+	return requested, nil
+}
+
+func (r *jobRepository) MarkCancelled(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE analysis_jobs
+		SET status = 'cancelled',
+			locked_by = NULL,
+			finished_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $1 AND status = 'running'
+	`, id)
+	if err != nil {
+		return fmt.Errorf("cancel job: %w", err)
+	}
+	return nil
+}
+
+func (r *jobRepository) Complete(ctx context.Context, id int64, result *models.Analysis) error {
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("marshal job result: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		UPDATE analysis_jobs
+		SET status = 'done',
+			result = $2::jsonb,
+			last_error = NULL,
+			locked_by = NULL,
+			finished_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $1
+	`, id, string(resultJSON))
+	if err != nil {
+		return fmt.Errorf("complete job: %w", err)
+	}
+	return nil
+}
+
+func (r *jobRepository) Fail(ctx context.Context, id int64, errMsg string, retryAfter time.Duration) (bool, error) {
+	var status models.JobStatus
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE analysis_jobs
+		SET status = CASE WHEN attempts < max_attempts THEN 'queued' ELSE 'failed' END,
+			run_after = NOW() + make_interval(secs => $3),
+			last_error = $2,
+			locked_by = NULL,
+			finished_at = CASE WHEN attempts < max_attempts THEN NULL ELSE NOW() END,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING status
+	`, id, errMsg, retryAfter.Seconds()).Scan(&status)
+	if err != nil {
+		return false, fmt.Errorf("fail job: %w", err)
+	}
+	return status == models.JobQueued, nil
+}
+
+func (r *jobRepository) Release(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE analysis_jobs
+		SET status = 'queued',
+			attempts = GREATEST(attempts - 1, 0),
+			locked_by = NULL,
+			updated_at = NOW()
+		WHERE id = $1 AND status = 'running'
+	`, id)
+	if err != nil {
+		return fmt.Errorf("release job: %w", err)
+	}
+	return nil
+}
+
+func (r *jobRepository) RequeueStale(ctx context.Context, source models.JobSource, staleAfter time.Duration) ([]*models.Job, error) {
+	query := `
+		UPDATE analysis_jobs
+		SET status = CASE
+				WHEN cancel_requested THEN 'cancelled'
+				WHEN attempts < max_attempts THEN 'queued'
+				ELSE 'failed'
+			END,
+			last_error = 'worker stopped responding',
+			locked_by = NULL,
+			finished_at = CASE WHEN attempts < max_attempts AND NOT cancel_requested THEN NULL ELSE NOW() END,
+			updated_at = NOW()
+		WHERE status = 'running' AND source = $2 AND heartbeat_at < NOW() - make_interval(secs => $1)
+		RETURNING` + jobColumns
+
+	rows, err := r.db.QueryContext(ctx, query, staleAfter.Seconds(), source)
+	if err != nil {
+		return nil, fmt.Errorf("requeue stale jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var finished []*models.Job
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan job: %w", err)
+		}
+		if j.Status != models.JobQueued {
+			finished = append(finished, j)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return finished, nil
+}
+
+func scanJob(row rowScanner) (*models.Job, error) {
+	var j models.Job
+	var attachments, result string
+	var finishedAt sql.NullTime
+	if err := row.Scan(
+		&j.ID,
+		&j.Source,
+		&j.UserID,
+		&j.ChatID,
+		&j.MessageID,
+		&j.CallbackURL,
+		&j.IdeaText,
+		&j.Brief,
+		&attachments,
+		&j.Status,
+		&j.Attempts,
+		&j.MaxAttempts,
+		&j.LastError,
+		&result,
+		&j.AnalysisID,
+		&j.CreatedAt,
+		&finishedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if finishedAt.Valid {
+		j.FinishedAt = &finishedAt.Time
+	}
+	var err error
+	if j.Attachments, err = decodeAttachments(attachments); err != nil {
+		return nil, err
+	}
+	if result != "" {
+		var a models.Analysis
+		if err := json.Unmarshal([]byte(result), &a); err != nil {
+			return nil, fmt.Errorf("unmarshal job result: %w", err)
+		}
+		j.Result = &a
+	}
+	return &j, nil
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"BoardAI/internal/models"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+func (r *analysisRepository) Revise(ctx context.Context, a *models.Analysis, rev *models.Revision) error {
+	c, err := encodeResult(a)
+	if err != nil {
+		return err
+	}
+	assessment, err := nullableJSON(rev.Assessment)
+	if err != nil {
+		return fmt.Errorf("marshal revision assessment: %w", err)
+	}
+	verdict, err := nullableJSON(rev.Verdict)
+	if err != nil {
+		return fmt.Errorf("marshal revision verdict: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE analyses
+		SET reports = $2::jsonb,
+			moderator = $3::jsonb,
+			decision = $4,
+			score = $5,
+			risks = $6::jsonb,
+			recommendations = $7::jsonb,
+			dimension_scores = $8::jsonb,
+			agent_runs = $9::jsonb,
+			prompt_tokens = $10,
+			completion_tokens = $11,
+			cost_usd = $12
+		WHERE id = $1 AND status = 'completed'
+	`,
+		a.ID,
+		c.reports,
+		c.moderator,
+		c.verdict.decision,
+		c.verdict.score,
+		c.verdict.risks,
+		c.verdict.recommendations,
+		c.verdict.dimensions,
+		c.agentRuns,
+		a.PromptTokens,
+		a.CompletionTokens,
+		a.CostUSD,
+	)
+	if err != nil {
+		return fmt.Errorf("revise analysis: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("revise analysis rows affected: %w", err)
+	}
+	if n == 0 {
+		// Анализ удалили, пока эксперт готовил новую версию.
+		return fmt.Errorf("analysis %d is not completed", a.ID)
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO analysis_revisions (analysis_id, kind, role, model, content, assessment, verdict)
+		VALUES ($1, $2, $3, $4, $5, $6::jsonb, $7::jsonb)
+		RETURNING id, created_at
+	`, a.ID, rev.Kind, rev.Role, rev.Model, rev.Content, assessment, verdict).Scan(&rev.ID, &rev.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("add revision: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit revision: %w", err)
+	}
+	return nil
+}
+
+func (r *analysisRepository) Revisions(ctx context.Context, analysisID int64) ([]*models.Revision, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, analysis_id, kind, role, model, content,
+			COALESCE(assessment::text, '') AS assessment,
+			COALESCE(verdict::text, '')    AS verdict,
+			created_at
+		FROM analysis_revisions
+		WHERE analysis_id = $1
+		ORDER BY id
+	`, analysisID)
+	if err != nil {
+		return nil, fmt.Errorf("list revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var result []*models.Revision
+	for rows.Next() {
+		var rev models.Revision
+		var assessment, verdict string
+		if err := rows.Scan(&rev.ID, &rev.AnalysisID, &rev.Kind, &rev.Role, &rev.Model, &rev.Content,
+			&assessment, &verdict, &rev.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan revision: %w", err)
+		}
+		if assessment != "" {
+			if err := json.Unmarshal([]byte(assessment), &rev.Assessment); err != nil {
+				return nil, fmt.Errorf("unmarshal revision assessment: %w", err)
+			}
+		}
+		if verdict != "" {
+			if err := json.Unmarshal([]byte(verdict), &rev.Verdict); err != nil {
+				return nil, fmt.Errorf("unmarshal revision verdict: %w", err)
+			}
+		}
+		result = append(result, &rev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return result, nil
+}
+
+// nullableJSON encodes v for a JSONB column; a nil pointer becomes NULL.
+func nullableJSON[T any](v *T) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"BoardAI/internal/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// UsageRepository reads the llm_usage ledger that AnalysisRepository.AddAgentRun writes.
+type UsageRepository interface {
+	// Record adds to the ledger an agent call made outside of an analysis run, such as a follow-up
+	// answer; the totals of the analysis are not changed.
+	Record(ctx context.Context, analysisID, userID int64, run models.AgentRun) error
+	// Total returns the usage of all users since the given time.
+	Total(ctx context.Context, since time.Time) (*models.UsageSummary, error)
+	// ByUser returns the usage of one user since the given time.
+	ByUser(ctx context.Context, userID int64, since time.Time) (*models.UsageSummary, error)
+	// TopUsers returns per-user usage since the given time, most expensive first.
+	TopUsers(ctx context.Context, since time.Time, limit int) ([]*models.UsageSummary, error)
+	// ByModel returns per-model usage since the given time, most expensive first.
+	ByModel(ctx context.Context, since time.Time) ([]*models.UsageSummary, error)
+}
+
+type usageRepository struct {
+	db *sql.DB
+}
+
+func NewUsageRepository(db *sql.DB) UsageRepository {
+	return &usageRepository{db: db}
+}
+
+const usageAggregates = `
+			COUNT(DISTINCT analysis_id)          AS analyses,
+			COUNT(*)                             AS calls,
+			COALESCE(SUM(prompt_tokens), 0)      AS prompt_tokens,
+			COALESCE(SUM(completion_tokens), 0)  AS completion_tokens,
+			COALESCE(SUM(cost_usd), 0)::float8   AS cost_usd`
+
+func (r *usageRepository) Record(ctx context.Context, analysisID, userID int64, run models.AgentRun) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO llm_usage (analysis_id, user_id, role, stage, model, prompt_tokens, completion_tokens, cost_usd, latency_ms, duration_ms)
+		VALUES (NULLIF($1, 0), $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`, analysisID, userID, run.Role, run.Stage, run.Model, run.PromptTokens, run.CompletionTokens, run.CostUSD, run.LatencyMs, run.DurationMs)
+	if err != nil {
+		return fmt.Errorf("record llm usage: %w", err)
+	}
+	return nil
+}
+
+func (r *usageRepository) Total(ctx context.Context, since time.Time) (*models.UsageSummary, error) {
+	query := `SELECT` + usageAggregates + `
+		FROM llm_usage
+		WHERE created_at >= $1
+	`
+
+	var s models.UsageSummary
+	if err := scanUsage(r.db.QueryRowContext(ctx, query, since), &s); err != nil {
+		return nil, fmt.Errorf("total usage: %w", err)
+	}
+	return &s, nil
+}
+
+func (r *usageRepository) ByUser(ctx context.Context, userID int64, since time.Time) (*models.UsageSummary, error) {
+	query := `SELECT` + usageAggregates + `
+		FROM llm_usage
+		WHERE user_id = $1 AND created_at >= $2
+	`
+
+	s := models.UsageSummary{UserID: userID}
+	if err := scanUsage(r.db.QueryRowContext(ctx, query, userID, since), &s); err != nil {
+		return nil, fmt.Errorf("user usage: %w", err)
+	}
+	return &s, nil
+}
+
+func (r *usageRepository) TopUsers(ctx context.Context, since time.Time, limit int) ([]*models.UsageSummary, error) {
+	limit, _ = normalizePage(limit, 0)
+
+	query := `SELECT user_id,` + usageAggregates + `
+		FROM llm_usage
+		WHERE created_at >= $1
+		GROUP BY user_id
+		ORDER BY cost_usd DESC, prompt_tokens + completion_tokens DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, since, limit)
+	if err != nil {
+		return nil, fmt.Errorf("top users usage: %w", err)
+	}
+	defer rows.Close()
+
+	var result []*models.UsageSummary
+	for rows.Next() {
+		var s models.UsageSummary
+		if err := rows.Scan(&s.UserID, &s.Analyses, &s.Calls, &s.PromptTokens, &s.CompletionTokens, &s.CostUSD); err != nil {
+			return nil, fmt.Errorf("scan usage: %w", err)
+		}
+		result = append(result, &s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return result, nil
+}
+
+func (r *usageRepository) ByModel(ctx context.Context, since time.Time) ([]*models.UsageSummary, error) {
+	query := `SELECT model,` + usageAggregates + `
+		FROM llm_usage
+		WHERE created_at >= $1
+		GROUP BY model
+		ORDER BY cost_usd DESC, prompt_tokens + completion_tokens DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, since)
+	if err != nil {
+		return nil, fmt.Errorf("model usage: %w", err)
+	}
+	defer rows.Close()
+
+	var result []*models.UsageSummary
+	for rows.Next() {
+		var s models.UsageSummary
+		if err := rows.Scan(&s.Model, &s.Analyses, &s.Calls, &s.PromptTokens, &s.CompletionTokens, &s.CostUSD); err != nil {
+			return nil, fmt.Errorf("scan usage: %w", err)
+		}
+		result = append(result, &s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+	return result, nil
+}
+
+func scanUsage(row rowScanner, s *models.UsageSummary) error {
+	return row.Scan(&s.Analyses, &s.Calls, &s.PromptTokens, &s.CompletionTokens, &s.CostUSD)
+}
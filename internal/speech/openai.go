@@ -0,0 +1,39 @@
+package speech
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// OpenAITranscriber calls an OpenAI-compatible /audio/transcriptions endpoint; baseURL includes
+// the API version, e.g. http://localhost:8000/v1.
+type OpenAITranscriber struct {
+	baseURL  string
+	apiToken string
+	model    string
+	language string
+	client   *http.Client
+}
+
+func NewOpenAITranscriber(baseURL, apiToken, model, language string) *OpenAITranscriber {
+	return &OpenAITranscriber{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		apiToken: apiToken,
+		model:    model,
+		language: language,
+		client:   &http.Client{},
+	}
+}
+
+func (o *OpenAITranscriber) Transcribe(ctx context.Context, name string, audio []byte) (string, error) {
+	var headers map[string]string
+	if o.apiToken != "" {
+		headers = map[string]string{"Authorization": "Bearer " + o.apiToken}
+	}
+	return postAudio(ctx, o.client, o.baseURL+"/audio/transcriptions", headers, map[string]string{
+		"model":           o.model,
+		"language":        o.language,
+		"response_format": "json",
+	}, name, audio)
+}
@@ -0,0 +1,115 @@
+// Package speech turns voice messages into text with a pluggable speech-to-text backend.
+package speech
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// Transcriber converts recorded speech into text.
+type Transcriber interface {
+	// Transcribe returns the text spoken in audio. name is the file name with its extension:
+	// backends use it to pick the decoder.
+	Transcribe(ctx context.Context, name string, audio []byte) (string, error)
+}
+
+const (
+	// BackendWhisper — сервер whisper.cpp (examples/server, эндпоинт /inference).
+	BackendWhisper = "whisper"
+	// BackendOpenAI — OpenAI-совместимый /audio/transcriptions (faster-whisper-server, LocalAI, OpenAI).
+	BackendOpenAI = "openai"
+	// BackendNone выключает распознавание речи.
+	BackendNone = "none"
+)
+
+// Config selects and configures the backend.
+type Config struct {
+	Backend string
+	URL     string
+	// Model нужна OpenAI-совместимым серверам; whisper.cpp работает с моделью, с которой запущен.
+	Model    string
+	APIToken string
+	// Language — код языка речи (ru, en); пустое значение — автоопределение.
+	Language string
+}
+
+// New builds the configured transcriber. With BackendNone or an empty backend it returns nil:
+// voice messages are then not accepted.
+func New(cfg Config) (Transcriber, error) {
+	switch cfg.Backend {
+	case "", BackendNone:
+		return nil, nil
+	case BackendWhisper:
+		return NewWhisperServer(cfg.URL, cfg.Language), nil
+	case BackendOpenAI:
+		return NewOpenAITranscriber(cfg.URL, cfg.APIToken, cfg.Model, cfg.Language), nil
+	default:
+		return nil, fmt.Errorf("unknown transcriber %q, expected %s, %s or %s", cfg.Backend, BackendWhisper, BackendOpenAI, BackendNone)
+	}
+}
+
+// transcription is the JSON answer of both backends; whisper.cpp reports failures in the error field.
+type transcription struct {
+	Text  string `json:"text"`
+	Error string `json:"error"`
+}
+
+// postAudio uploads the audio as the "file" field of a multipart form together with fields
+// and returns the recognized text.
+func postAudio(ctx context.Context, client *http.Client, url string, headers, fields map[string]string, name string, audio []byte) (string, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	part, err := w.CreateFormFile("file", name)
+	if err != nil {
+		return "", fmt.Errorf("create form file: %w", err)
+	}
+	if _, err := part.Write(audio); err != nil {
+		return "", fmt.Errorf("write audio: %w", err)
+	}
+	for k, v := range fields {
+		if v == "" {
+			continue
+		}
+		if err := w.WriteField(k, v); err != nil {
+			return "", fmt.Errorf("write field %s: %w", k, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return "", fmt.Errorf("close form: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, &body)
+	if err != nil {
+		return "", fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("http do: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("transcriber http status: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var out transcription
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("decode transcription: %w", err)
+	}
+	if out.Error != "" {
+		return "", fmt.Errorf("transcriber: %s", out.Error)
+	}
+	return strings.TrimSpace(out.Text), nil
+}
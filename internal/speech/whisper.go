@@ -0,0 +1,35 @@
+package speech
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// WhisperServer talks to the whisper.cpp HTTP server. Telegram sends voice notes as OGG/Opus,
+// so the server has to be started with --convert (it decodes the audio with ffmpeg).
+type WhisperServer struct {
+	baseURL  string
+	language string
+	client   *http.Client
+}
+
+func NewWhisperServer(baseURL, language string) *WhisperServer {
+	return &WhisperServer{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		language: language,
+		client:   &http.Client{},
+	}
+}
+
+func (w *WhisperServer) Transcribe(ctx context.Context, name string, audio []byte) (string, error) {
+	language := w.language
+	if language == "" {
+		language = "auto"
+	}
+	return postAudio(ctx, w.client, w.baseURL+"/inference", nil, map[string]string{
+		"response_format": "json",
+		"language":        language,
+		"temperature":     "0.0",
+	}, name, audio)
+}
@@ -0,0 +1,66 @@
+package state
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore keeps sessions in process memory; they are lost on restart and not shared
+// between replicas. Useful for local runs and single-process setups.
+type MemoryStore struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	sessions map[int64]*Session
+}
+
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	return &MemoryStore{
+		ttl:      ttl,
+		sessions: make(map[int64]*Session),
+	}
+}
+
+func (m *MemoryStore) Get(ctx context.Context, userID int64) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.load(userID)
+	return &s, nil
+}
+
+func (m *MemoryStore) Update(ctx context.Context, userID int64, fn func(s *Session)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.load(userID)
+	fn(&s)
+	s.UserID = userID
+	s.UpdatedAt = time.Now()
+	m.sessions[userID] = &s
+	return nil
+}
+
+func (m *MemoryStore) Cleanup(ctx context.Context) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var n int64
+	for id, s := range m.sessions {
+		if m.expired(s) {
+			delete(m.sessions, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// load returns a copy of the stored session, or a fresh one if it is missing or expired.
+func (m *MemoryStore) load(userID int64) Session {
+	s, ok := m.sessions[userID]
+	if !ok || m.expired(s) {
+		return *newSession(userID)
+	}
+	return *s
+}
+
+func (m *MemoryStore) expired(s *Session) bool {
+	return m.ttl > 0 && time.Since(s.UpdatedAt) > m.ttl
+}
@@ -0,0 +1,176 @@
+package state
+
+import (
+	"BoardAI/internal/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// PostgresStore keeps sessions in the bot_sessions table, so they survive restarts and are
+// shared by all bot replicas.
+type PostgresStore struct {
+	db  *sql.DB
+	ttl time.Duration
+}
+
+func NewPostgresStore(db *sql.DB, ttl time.Duration) *PostgresStore {
+	return &PostgresStore{db: db, ttl: ttl}
+}
+
+const sessionColumns = `
+			user_id,
+			state,
+			COALESCE(pending_job_id, 0)       AS pending_job_id,
+			COALESCE(last_job_id, 0)          AS last_job_id,
+			COALESCE(followup_analysis_id, 0) AS followup_analysis_id,
+			followup_role,
+			pending_attachments::text         AS pending_attachments,
+			pending_transcript,
+			pending_idea,
+			interview::text                   AS interview,
+			compare_ids::text                 AS compare_ids,
+			updated_at`
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func (p *PostgresStore) Get(ctx context.Context, userID int64) (*Session, error) {
+	query := `SELECT` + sessionColumns + `
+		FROM bot_sessions
+		WHERE user_id = $1
+	`
+
+	s, err := p.scan(p.db.QueryRowContext(ctx, query, userID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return newSession(userID), nil
+		}
+		return nil, fmt.Errorf("get session: %w", err)
+	}
+	return s, nil
+}
+
+func (p *PostgresStore) Update(ctx context.Context, userID int64, fn func(s *Session)) error {
+	tx, err := p.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin session update: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Строку создаем заранее, чтобы FOR UPDATE было что блокировать.
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO bot_sessions (user_id) VALUES ($1)
+		ON CONFLICT (user_id) DO NOTHING
+	`, userID); err != nil {
+		return fmt.Errorf("create session: %w", err)
+	}
+
+	s, err := p.scan(tx.QueryRowContext(ctx, `SELECT`+sessionColumns+`
+		FROM bot_sessions
+		WHERE user_id = $1
+		FOR UPDATE
+	`, userID))
+	if err != nil {
+		return fmt.Errorf("lock session: %w", err)
+	}
+
+	fn(s)
+
+	pending := s.PendingAttachments
+	if pending == nil {
+		pending = []models.Attachment{}
+	}
+	pendingJSON, err := json.Marshal(pending)
+	if err != nil {
+		return fmt.Errorf("marshal pending attachments: %w", err)
+	}
+	interview := s.Interview
+	if interview == nil {
+		interview = []models.ClarifyingAnswer{}
+	}
+	interviewJSON, err := json.Marshal(interview)
+	if err != nil {
+		return fmt.Errorf("marshal interview: %w", err)
+	}
+	compareIDs := s.CompareIDs
+	if compareIDs == nil {
+		compareIDs = []int64{}
+	}
+	compareJSON, err := json.Marshal(compareIDs)
+	if err != nil {
+		return fmt.Errorf("marshal compare ids: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE bot_sessions
+		SET state = $2,
+			pending_job_id = NULLIF($3, 0),
+			last_job_id = NULLIF($4, 0),
+			followup_analysis_id = NULLIF($5, 0),
+			followup_role = $6,
+			pending_attachments = $7::jsonb,
+			pending_transcript = $8,
+			pending_idea = $9,
+			interview = $10::jsonb,
+			compare_ids = $11::jsonb,
+			updated_at = NOW()
+		WHERE user_id = $1
+	`, userID, s.State, s.PendingJobID, s.LastJobID, s.FollowUpAnalysisID, s.FollowUpRole, string(pendingJSON),
+		s.PendingTranscript, s.PendingIdea, string(interviewJSON), string(compareJSON))
+	if err != nil {
+		return fmt.Errorf("save session: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit session update: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresStore) Cleanup(ctx context.Context) (int64, error) {
+	if p.ttl <= 0 {
+		return 0, nil
+	}
+	res, err := p.db.ExecContext(ctx, `
+		DELETE FROM bot_sessions
+		WHERE updated_at < NOW() - make_interval(secs => $1)
+	`, p.ttl.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("cleanup sessions: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// scan reads a session row; an expired session is returned as a fresh idle one.
+func (p *PostgresStore) scan(row rowScanner) (*Session, error) {
+	var s Session
+	var pending, interview, compareIDs string
+	if err := row.Scan(&s.UserID, &s.State, &s.PendingJobID, &s.LastJobID, &s.FollowUpAnalysisID, &s.FollowUpRole, &pending,
+		&s.PendingTranscript, &s.PendingIdea, &interview, &compareIDs, &s.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if pending != "" && pending != "[]" {
+		if err := json.Unmarshal([]byte(pending), &s.PendingAttachments); err != nil {
+			return nil, fmt.Errorf("unmarshal pending attachments: %w", err)
+		}
+	}
+	if interview != "" && interview != "[]" {
+		if err := json.Unmarshal([]byte(interview), &s.Interview); err != nil {
+			return nil, fmt.Errorf("unmarshal interview: %w", err)
+		}
+	}
+	if compareIDs != "" && compareIDs != "[]" {
+		if err := json.Unmarshal([]byte(compareIDs), &s.CompareIDs); err != nil {
+			return nil, fmt.Errorf("unmarshal compare ids: %w", err)
+		}
+	}
+	if p.ttl > 0 && time.Since(s.UpdatedAt) > p.ttl {
+		return newSession(s.UserID), nil
+	}
+	return &s, nil
+}
@@ -0,0 +1,72 @@
+// Package state keeps per-user conversation state of the bot: where the user is in the dialog
+// and which analyses they are waiting for or have just received.
+package state
+
+import (
+	"BoardAI/internal/models"
+	"context"
+	"time"
+)
+
+// Idle is the state of a user with no session or an expired one.
+const Idle = "IDLE"
+
+// Session is one user's conversation state.
+type Session struct {
+	UserID int64
+	State  string
+	// PendingJobID — задача в очереди или в работе; LastJobID — последняя завершенная задача с результатом.
+	PendingJobID int64
+	LastJobID    int64
+	// FollowUpAnalysisID и FollowUpRole — анализ и эксперт, с которым пользователь сейчас разговаривает.
+	FollowUpAnalysisID int64
+	FollowUpRole       string
+	// PendingAttachments — документы, присланные до текста идеи; уходят в следующий анализ.
+	PendingAttachments []models.Attachment
+	// PendingTranscript — расшифровка голосового сообщения, которую пользователь еще не подтвердил.
+	PendingTranscript string
+	// PendingIdea и Interview — идея и ответы автора на уточняющие вопросы, пока идет интервью.
+	PendingIdea string
+	Interview   []models.ClarifyingAnswer
+	// CompareIDs — анализы, отмеченные для сравнения.
+	CompareIDs []int64
+	UpdatedAt  time.Time
+}
+
+// Store persists sessions. Sessions not updated for longer than the store's TTL are treated
+// as missing.
+type Store interface {
+	// Get returns the user's session or a fresh idle one.
+	Get(ctx context.Context, userID int64) (*Session, error)
+	// Update applies fn to the user's session atomically and saves the result.
+	Update(ctx context.Context, userID int64, fn func(s *Session)) error
+	// Cleanup deletes expired sessions and returns how many were removed.
+	Cleanup(ctx context.Context) (int64, error)
+}
+
+func newSession(userID int64) *Session {
+	return &Session{UserID: userID, State: Idle}
+}
+
+// RunCleanup removes expired sessions every interval until ctx is cancelled.
+func RunCleanup(ctx context.Context, store Store, interval time.Duration, logf func(format string, args ...any)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := store.Cleanup(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					logf("session cleanup error: %v", err)
+				}
+				continue
+			}
+			if n > 0 {
+				logf("removed %d expired session(s)", n)
+			}
+		}
+	}
+}
@@ -0,0 +1 @@
+ALTER TABLE analyses ADD COLUMN IF NOT EXISTS debate JSONB NULL;
@@ -0,0 +1,11 @@
+-- Отчеты экспертов теперь хранятся списком, чтобы совет мог состоять из любых ролей.
+ALTER TABLE analyses ADD COLUMN IF NOT EXISTS reports JSONB NULL;
+
+-- Переносим отчеты из старых колонок, которые заполнялись до появления описания совета.
+UPDATE analyses
+SET reports = (
+    SELECT COALESCE(jsonb_agg(r.value ORDER BY r.ord), '[]'::jsonb)
+    FROM (VALUES (1, strategist), (2, financier), (3, auditor), (4, analyst)) AS r(ord, value)
+    WHERE r.value IS NOT NULL
+)
+WHERE reports IS NULL;
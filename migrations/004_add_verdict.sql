@@ -0,0 +1,22 @@
+-- Структурированный вердикт модератора. Полный JSON по-прежнему лежит в moderator->'verdict',
+-- а отдельные колонки нужны для выборок и графиков.
+ALTER TABLE analyses ADD COLUMN IF NOT EXISTS decision         TEXT     NULL;
+ALTER TABLE analyses ADD COLUMN IF NOT EXISTS score            SMALLINT NULL;
+ALTER TABLE analyses ADD COLUMN IF NOT EXISTS risks            JSONB    NULL;
+ALTER TABLE analyses ADD COLUMN IF NOT EXISTS recommendations  JSONB    NULL;
+ALTER TABLE analyses ADD COLUMN IF NOT EXISTS dimension_scores JSONB    NULL;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'analyses_decision_check') THEN
+        ALTER TABLE analyses ADD CONSTRAINT analyses_decision_check
+            CHECK (decision IS NULL OR decision IN ('go', 'no-go', 'pivot'));
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'analyses_score_check') THEN
+        ALTER TABLE analyses ADD CONSTRAINT analyses_score_check
+            CHECK (score IS NULL OR score BETWEEN 0 AND 100);
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_analyses_decision ON analyses (decision);
+CREATE INDEX IF NOT EXISTS idx_analyses_score ON analyses (score);
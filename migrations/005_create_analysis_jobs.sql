@@ -0,0 +1,24 @@
+-- Очередь анализов: переживает рестарты бота, воркеры забирают задачи через FOR UPDATE SKIP LOCKED.
+CREATE TABLE IF NOT EXISTS analysis_jobs (
+    id            BIGSERIAL    PRIMARY KEY,
+    user_id       BIGINT       NOT NULL,
+    chat_id       BIGINT       NOT NULL,
+    message_id    INTEGER      NOT NULL DEFAULT 0,
+    idea_text     TEXT         NOT NULL,
+    status        TEXT         NOT NULL DEFAULT 'queued'
+                  CHECK (status IN ('queued', 'running', 'done', 'failed')),
+    attempts      INTEGER      NOT NULL DEFAULT 0,
+    max_attempts  INTEGER      NOT NULL DEFAULT 3,
+    last_error    TEXT         NULL,
+    result        JSONB        NULL,
+    locked_by     TEXT         NULL,
+    heartbeat_at  TIMESTAMPTZ  NULL,
+    run_after     TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    created_at    TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    finished_at   TIMESTAMPTZ  NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_analysis_jobs_queued ON analysis_jobs (run_after, id) WHERE status = 'queued';
+CREATE INDEX IF NOT EXISTS idx_analysis_jobs_running ON analysis_jobs (heartbeat_at) WHERE status = 'running';
+CREATE INDEX IF NOT EXISTS idx_analysis_jobs_user ON analysis_jobs (user_id, status);
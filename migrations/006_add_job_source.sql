@@ -0,0 +1,9 @@
+-- Задачи приходят не только из Telegram, но и из HTTP API: каждый процесс забирает задачи своего источника.
+ALTER TABLE analysis_jobs
+    ADD COLUMN IF NOT EXISTS source       TEXT   NOT NULL DEFAULT 'telegram'
+                             CHECK (source IN ('telegram', 'api')),
+    ADD COLUMN IF NOT EXISTS callback_url TEXT   NULL,
+    ADD COLUMN IF NOT EXISTS analysis_id  BIGINT NULL REFERENCES analyses (id) ON DELETE SET NULL;
+
+DROP INDEX IF EXISTS idx_analysis_jobs_queued;
+CREATE INDEX IF NOT EXISTS idx_analysis_jobs_queued ON analysis_jobs (source, run_after, id) WHERE status = 'queued';
@@ -0,0 +1,10 @@
+-- Состояние диалога с ботом: переживает рестарты и общее для всех реплик.
+CREATE TABLE IF NOT EXISTS bot_sessions (
+    user_id         BIGINT       PRIMARY KEY,
+    state           TEXT         NOT NULL DEFAULT 'IDLE',
+    pending_job_id  BIGINT       NULL REFERENCES analysis_jobs (id) ON DELETE SET NULL,
+    last_job_id     BIGINT       NULL REFERENCES analysis_jobs (id) ON DELETE SET NULL,
+    updated_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_bot_sessions_updated_at ON bot_sessions (updated_at);
@@ -0,0 +1,17 @@
+-- Анализ сохраняется с момента запуска: статус, время выполнения, ошибки и длительность работы агентов.
+-- Уже существующие записи были сохранены вручную после успешного анализа, поэтому они completed и в избранном.
+ALTER TABLE analyses
+    ADD COLUMN IF NOT EXISTS status      TEXT        NOT NULL DEFAULT 'completed'
+                             CHECK (status IN ('running', 'completed', 'failed', 'cancelled')),
+    ADD COLUMN IF NOT EXISTS error       TEXT        NULL,
+    ADD COLUMN IF NOT EXISTS started_at  TIMESTAMPTZ NULL,
+    ADD COLUMN IF NOT EXISTS finished_at TIMESTAMPTZ NULL,
+    ADD COLUMN IF NOT EXISTS agent_runs  JSONB       NOT NULL DEFAULT '[]'::jsonb,
+    ADD COLUMN IF NOT EXISTS pinned      BOOLEAN     NULL;
+
+UPDATE analyses SET pinned = TRUE WHERE pinned IS NULL;
+ALTER TABLE analyses ALTER COLUMN pinned SET DEFAULT FALSE;
+ALTER TABLE analyses ALTER COLUMN pinned SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_analyses_status ON analyses (status);
+CREATE INDEX IF NOT EXISTS idx_analyses_user_pinned ON analyses (user_id, pinned DESC, created_at DESC);
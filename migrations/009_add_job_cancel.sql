@@ -0,0 +1,8 @@
+-- Отмена анализа пользователем. Флаг cancel_requested видят все реплики: воркер, выполняющий задачу,
+-- проверяет его и прерывает запросы к LLM.
+ALTER TABLE analysis_jobs
+    ADD COLUMN IF NOT EXISTS cancel_requested BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE analysis_jobs DROP CONSTRAINT IF EXISTS analysis_jobs_status_check;
+ALTER TABLE analysis_jobs ADD CONSTRAINT analysis_jobs_status_check
+    CHECK (status IN ('queued', 'running', 'done', 'failed', 'cancelled'));
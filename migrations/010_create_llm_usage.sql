@@ -0,0 +1,24 @@
+-- Учет токенов и стоимости. llm_usage — журнал вызовов агентов: он переживает удаление анализа,
+-- поэтому отчеты по пользователям строятся по нему, а итоги в analyses нужны для карточки анализа.
+ALTER TABLE analyses
+    ADD COLUMN IF NOT EXISTS prompt_tokens     BIGINT        NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS completion_tokens BIGINT        NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS cost_usd          NUMERIC(14,6) NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS llm_usage (
+    id                BIGSERIAL     PRIMARY KEY,
+    analysis_id       BIGINT        NULL REFERENCES analyses (id) ON DELETE SET NULL,
+    user_id           BIGINT        NOT NULL,
+    role              TEXT          NOT NULL,
+    stage             TEXT          NOT NULL,
+    model             TEXT          NOT NULL DEFAULT '',
+    prompt_tokens     INTEGER       NOT NULL DEFAULT 0,
+    completion_tokens INTEGER       NOT NULL DEFAULT 0,
+    cost_usd          NUMERIC(14,6) NOT NULL DEFAULT 0,
+    latency_ms        BIGINT        NOT NULL DEFAULT 0,
+    duration_ms       BIGINT        NOT NULL DEFAULT 0,
+    created_at        TIMESTAMPTZ   NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_llm_usage_user_created ON llm_usage (user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_llm_usage_created ON llm_usage (created_at);
@@ -0,0 +1,2 @@
+-- Квоты считают задачи пользователя за текущие сутки.
+CREATE INDEX IF NOT EXISTS idx_analysis_jobs_user_created ON analysis_jobs (user_id, created_at);
@@ -0,0 +1,17 @@
+-- Вопросы пользователя эксперту по готовому анализу и ответы эксперта.
+CREATE TABLE IF NOT EXISTS analysis_followups (
+    id           BIGSERIAL    PRIMARY KEY,
+    analysis_id  BIGINT       NOT NULL REFERENCES analyses (id) ON DELETE CASCADE,
+    user_id      BIGINT       NOT NULL,
+    role         TEXT         NOT NULL,
+    author       TEXT         NOT NULL CHECK (author IN ('user', 'expert')),
+    content      TEXT         NOT NULL,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_analysis_followups_thread ON analysis_followups (analysis_id, role, id);
+
+-- С кем из экспертов пользователь сейчас разговаривает.
+ALTER TABLE bot_sessions
+    ADD COLUMN IF NOT EXISTS followup_analysis_id BIGINT NULL REFERENCES analyses (id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS followup_role        TEXT   NOT NULL DEFAULT '';
@@ -0,0 +1,10 @@
+-- Документы, приложенные к идее (текст и таблицы, извлеченные из PDF, DOCX, XLSX).
+ALTER TABLE analysis_jobs
+    ADD COLUMN IF NOT EXISTS attachments JSONB NOT NULL DEFAULT '[]';
+
+ALTER TABLE analyses
+    ADD COLUMN IF NOT EXISTS attachments JSONB NOT NULL DEFAULT '[]';
+
+-- Документы, присланные до текста идеи, ждут его в сессии.
+ALTER TABLE bot_sessions
+    ADD COLUMN IF NOT EXISTS pending_attachments JSONB NOT NULL DEFAULT '[]';
@@ -0,0 +1,3 @@
+-- Расшифровка голосового сообщения ждет в сессии подтверждения пользователя.
+ALTER TABLE bot_sessions
+    ADD COLUMN IF NOT EXISTS pending_transcript TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,11 @@
+-- Бриф по ответам автора на уточняющие вопросы перед заседанием совета.
+ALTER TABLE analysis_jobs
+    ADD COLUMN IF NOT EXISTS brief TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE analyses
+    ADD COLUMN IF NOT EXISTS brief TEXT NOT NULL DEFAULT '';
+
+-- Идея и ответы автора, пока идет интервью.
+ALTER TABLE bot_sessions
+    ADD COLUMN IF NOT EXISTS pending_idea TEXT  NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS interview    JSONB NOT NULL DEFAULT '[]';
@@ -0,0 +1,3 @@
+-- Анализы, отмеченные пользователем для сравнения (/compare).
+ALTER TABLE bot_sessions
+    ADD COLUMN IF NOT EXISTS compare_ids JSONB NOT NULL DEFAULT '[]';
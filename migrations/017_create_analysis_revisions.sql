@@ -0,0 +1,14 @@
+-- Прежние версии отчетов и вердиктов, замененные повторным прогоном эксперта или модератора.
+CREATE TABLE IF NOT EXISTS analysis_revisions (
+    id           BIGSERIAL    PRIMARY KEY,
+    analysis_id  BIGINT       NOT NULL REFERENCES analyses (id) ON DELETE CASCADE,
+    kind         TEXT         NOT NULL CHECK (kind IN ('report', 'verdict')),
+    role         TEXT         NOT NULL,
+    model        TEXT         NOT NULL DEFAULT '',
+    content      TEXT         NOT NULL,
+    assessment   JSONB        NULL,
+    verdict      JSONB        NULL,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_analysis_revisions_analysis ON analysis_revisions (analysis_id, id);
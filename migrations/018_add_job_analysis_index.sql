@@ -0,0 +1,2 @@
+-- API отдает только анализы своих задач: поиск задачи по анализу.
+CREATE INDEX IF NOT EXISTS idx_analysis_jobs_analysis ON analysis_jobs (analysis_id);